@@ -4,32 +4,148 @@ import (
 	"IncursionBot/internal/ESI"
 	incursions "IncursionBot/internal/Incursions"
 	logging "IncursionBot/internal/Logging"
+	scheduler "IncursionBot/internal/Scheduler"
+	"IncursionBot/internal/Utils"
+	"context"
+	"sync"
 	"time"
 )
 
-func pollESI(incursionChan chan<- incursions.IncursionList) {
-	for {
-		incursionResponses, nextPollTime, err := esi.GetIncursions()
+const staleDataAge time.Duration = time.Minute * 15       // Incursion data older than this can't be trusted
+const healthCheckTimeout time.Duration = time.Second * 30 // Longest a check of ESI's status may take
+
+// Tracks how polling ESI for incursions is going
+type pollState struct {
+	mut         sync.Mutex
+	lastSuccess time.Time
+	failing     bool
+}
+
+var incursionPolls pollState
+
+func (state *pollState) succeeded() {
+	state.mut.Lock()
+	defer state.mut.Unlock()
+
+	state.lastSuccess = time.Now()
+	state.failing = false
+}
+
+func (state *pollState) failed() {
+	state.mut.Lock()
+	defer state.mut.Unlock()
+
+	state.failing = true
+}
+
+// Gets the time incursions were last successfully polled
+func (state *pollState) LastSuccess() time.Time {
+	state.mut.Lock()
+	defer state.mut.Unlock()
+
+	return state.lastSuccess
+}
+
+// Checks that the last poll succeeded and the data isn't stale
+func (state *pollState) Healthy() bool {
+	state.mut.Lock()
+	defer state.mut.Unlock()
+
+	return !state.failing && time.Since(state.lastSuccess) < staleDataAge
+}
+
+// Checks ESI's status every minute, so its health is known even when nothing is being polled
+func setupHealthChecks(ctx context.Context) {
+	check := func() {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+
+		err := esi.CheckHealth(checkCtx)
+		if err != nil && ctx.Err() == nil {
+			logging.Warningln("ESI health check failed", err)
+		}
+	}
+
+	everyMinute, _ := scheduler.ParseCron("* * * * *")
+	sched.Add("ESI health check", everyMinute, check)
+
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		check() // So !esi has something to show before the first scheduled check
+		checkSwagger(ctx)
+	}()
+}
+
+// Warns about any endpoint the bot uses that ESI's swagger spec says has been deprecated or changed
+func checkSwagger(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report, err := esi.CheckSwagger(checkCtx)
+	if err != nil {
+		return
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Field == "" {
+			logging.Warningf("ESI contract: %s: %s", issue.Endpoint, issue.Problem)
+		} else {
+			logging.Warningf("ESI contract: %s %s: %s", issue.Endpoint, issue.Field, issue.Problem)
+		}
+	}
+	logging.Infof("Checked the endpoints the bot uses against ESI swagger version %s, found %d issues", report.SwaggerVersion, len(report.Issues))
+}
+
+// Polls ESI for incursions until the context is cancelled
+func pollESI(ctx context.Context, incursionChan chan<- incursions.IncursionList) {
+	for ctx.Err() == nil {
+		pollCtx, cancel := context.WithTimeout(ctx, pollTimeout)
+		incursions, nextPollTime, err := pollIncursions(pollCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
 		if err != nil {
 			logging.Warningln("Error getting basic incursion data, sleeping 1 min then reattempting", err)
-			time.Sleep(time.Minute)
+			incursionPolls.failed()
+			updatePresence()
+			Utils.SleepContext(ctx, time.Minute)
 			continue
 		}
 
-		var incursions incursions.IncursionList
-		for _, response := range incursionResponses {
-			newIncursion := createIncursion(response, esi)
-			incursions = append(incursions, newIncursion)
+		incursionPolls.succeeded()
+
+		select {
+		case incursionChan <- incursions:
+		case <-ctx.Done():
+			return
 		}
 
-		incursionChan <- incursions
 		logging.Debugf("Sleeping until %s", nextPollTime.String())
-		time.Sleep(time.Until(nextPollTime))
+		Utils.SleepContext(ctx, time.Until(nextPollTime))
+	}
+}
+
+// Gets the current incursions with everything known about them, and when ESI will next have new data
+func pollIncursions(ctx context.Context) (incursions.IncursionList, time.Time, error) {
+	incursionResponses, nextPollTime, err := esi.GetIncursions(ctx)
+	if err != nil {
+		return nil, nextPollTime, err
 	}
+
+	var result incursions.IncursionList
+	for _, response := range incursionResponses {
+		newIncursion := createIncursion(ctx, response, esi)
+		result = append(result, newIncursion)
+	}
+
+	return result, nextPollTime, nil
 }
 
-func getDistance(stagingID int, client ESI.ESIClient, resultChan chan<- int) {
-	distance, err := client.GetRouteLength(homeSystem, stagingID)
+func getDistance(ctx context.Context, stagingID int, client ESI.ESIClient, resultChan chan<- int) {
+	distance, err := client.GetRouteLength(ctx, homeSystem, stagingID)
 	if err != nil {
 		logging.Errorf("Ran into error when getting system data for incursion in %d", stagingID)
 		resultChan <- -1
@@ -39,11 +155,11 @@ func getDistance(stagingID int, client ESI.ESIClient, resultChan chan<- int) {
 	resultChan <- distance
 }
 
-func getSov(systemID int, client ESI.ESIClient, resultChan chan<- string) {
-	resultChan <- GetSovOwner(systemID, &client)
+func getSov(ctx context.Context, systemID int, client ESI.ESIClient, resultChan chan<- string) {
+	resultChan <- GetSovOwner(ctx, systemID, &client)
 }
 
-func createIncursion(incursion ESI.IncursionResponse, client ESI.ESIClient) incursions.Incursion {
+func createIncursion(ctx context.Context, incursion ESI.IncursionResponse, client ESI.ESIClient) incursions.Incursion {
 	newIncursion := incursions.Incursion{
 		Constellation: incursions.NamedItem{ID: incursion.ConstellationID},
 		Influence:     incursion.Influence,
@@ -52,13 +168,13 @@ func createIncursion(incursion ESI.IncursionResponse, client ESI.ESIClient) incu
 		IsValid:       false,
 	}
 
-	distanceChan := make(chan int)
-	sovChan := make(chan string)
-	go getDistance(incursion.StagingID, client, distanceChan)
-	go getSov(incursion.StagingID, client, sovChan)
+	distanceChan := make(chan int, 1) // Buffered so the lookups can finish if we give up early
+	sovChan := make(chan string, 1)
+	go getDistance(ctx, incursion.StagingID, client, distanceChan)
+	go getSov(ctx, incursion.StagingID, client, sovChan)
 
-	newIncursion.Layout.StagingSystem = incursions.CreateNamedItem(incursion.StagingID, &client)
-	stagingData, err := client.GetSystemInfo(incursion.StagingID)
+	newIncursion.Layout.StagingSystem = incursions.CreateNamedItem(ctx, incursion.StagingID, &client)
+	stagingData, err := client.GetSystemInfo(ctx, incursion.StagingID)
 	if err != nil {
 		logging.Errorf("Ran into error when getting system data for incursion in %d", incursion.StagingID)
 		return newIncursion
@@ -67,7 +183,7 @@ func createIncursion(incursion ESI.IncursionResponse, client ESI.ESIClient) incu
 	newIncursion.SecStatus = stagingData.SecStatus
 	newIncursion.Security = incursions.ParseSecurityClass(newIncursion.SecStatus)
 
-	constData, err := client.GetConstInfo(incursion.ConstellationID)
+	constData, err := client.GetConstInfo(ctx, incursion.ConstellationID)
 	if err != nil {
 		logging.Errorf("Ran into error when getting system data for incursion in %d", incursion.StagingID)
 		return newIncursion
@@ -76,7 +192,7 @@ func createIncursion(incursion ESI.IncursionResponse, client ESI.ESIClient) incu
 	newIncursion.Constellation.Name = constData.Name
 	newIncursion.Region.ID = constData.RegionID
 
-	names, err := client.GetNames([]int{constData.RegionID})
+	names, err := client.GetNames(ctx, []int{constData.RegionID})
 	if err != nil {
 		logging.Errorf("Ran into error when getting system data for incursion in %d", incursion.StagingID)
 		return newIncursion
@@ -0,0 +1,77 @@
+package main
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	templates "IncursionBot/internal/Templates"
+	web "IncursionBot/internal/Web"
+	"fmt"
+	"strconv"
+)
+
+const calendarUIDSuffix string = "@incursion-bot"
+
+// Builds the calendar of predicted state changes, despawns and respawn windows
+func calendarEvents() []web.CalendarEvent {
+	var events []web.CalendarEvent
+
+	for _, incursion := range incManager.GetIncursions() {
+		if incursion.StateChanged.IsZero() {
+			continue // Up since before the bot started, so nothing can be predicted
+		}
+
+		end, err := incursion.TimeLeftInSpawn()
+		if err != nil {
+			continue
+		}
+
+		name := renderMessage(templates.IncursionName, incursion)
+		event := web.CalendarEvent{
+			UID:     "incursion-" + strconv.Itoa(incursion.Layout.StagingSystem.ID) + calendarUIDSuffix,
+			URL:     templates.DotlanSystemLink(incursion.Layout.StagingSystem.Name),
+			Start:   end,
+			Updated: incursion.StateChanged,
+		}
+
+		switch incursion.State {
+		case incursions.Established:
+			event.Summary = fmt.Sprintf("%s despawns (no later than)", incursion.Constellation.Name)
+			event.Description = fmt.Sprintf("%s is established and will despawn no later than this, earlier if it is run down.", name)
+		case incursions.Mobilizing:
+			event.Summary = fmt.Sprintf("%s mobilizing ends", incursion.Constellation.Name)
+			event.Description = fmt.Sprintf("%s stops mobilizing and starts withdrawing.", name)
+		case incursions.Withdrawing:
+			event.Summary = fmt.Sprintf("%s despawns", incursion.Constellation.Name)
+			event.Description = fmt.Sprintf("%s finishes withdrawing and despawns.", name)
+		}
+
+		events = append(events, event)
+	}
+
+	for _, security := range []incursions.SecurityClass{incursions.NullSec, incursions.LowSec} {
+		for _, window := range incManager.RespawnWindows(security) {
+			events = append(events, respawnWindowEvent(window))
+		}
+	}
+
+	return events
+}
+
+func respawnWindowEvent(window incursions.RespawnWindow) web.CalendarEvent {
+	summary := fmt.Sprintf("%ssec respawn window", window.Incursion.Security)
+	description := fmt.Sprintf("A new %ssec incursion can spawn to replace the one in %s (%s).",
+		window.Incursion.Security, window.Incursion.Constellation.Name, window.Incursion.Region.Name)
+
+	if window.UpperBound {
+		summary += " (opens no later than)"
+		description += " That incursion is still established, so the window may open earlier."
+	}
+
+	return web.CalendarEvent{
+		UID:         "respawn-" + strconv.Itoa(window.Incursion.Layout.StagingSystem.ID) + calendarUIDSuffix,
+		Summary:     summary,
+		Description: description,
+		Start:       window.Start,
+		End:         window.End,
+		Updated:     window.Incursion.StateChanged,
+	}
+}
@@ -2,22 +2,32 @@ package main
 
 import (
 	Chat "IncursionBot/internal/ChatClient"
+	"context"
 	"fmt"
+	"sort"
 )
 
-// Takes in a message from chat and returns the appropriate response message
-type commandFunc func(Chat.ChatMsg) string
+// Takes in a message from chat and returns the appropriate response message. Any ESI calls must use the context.
+type commandFunc func(context.Context, Chat.ChatMsg) Chat.Message
+
+// Structured version of a command for chat backends that support forms
+type commandForm struct {
+	fields  func(sender string) []Chat.FormField // Input to ask for, nil if there is none
+	execute func(sender string, values Chat.FormValues) Chat.FormResult
+}
 
 // Map of supported commands and their functions
 type CommandMap struct {
 	funcMap map[string]commandFunc
 	helpMap map[string]string
+	formMap map[string]commandForm
 }
 
 func NewCommandMap() CommandMap {
 	newMap := CommandMap{
 		funcMap: make(map[string]commandFunc),
 		helpMap: make(map[string]string),
+		formMap: make(map[string]commandForm),
 	}
 
 	newMap.AddCommand("help", newMap.HelpText, "This help message")
@@ -26,14 +36,20 @@ func NewCommandMap() CommandMap {
 }
 
 // Default command to send all the supported commands in the map
-func (m *CommandMap) HelpText(msg Chat.ChatMsg) string {
-	responseText := "Commands: \n"
+func (m *CommandMap) HelpText(ctx context.Context, msg Chat.ChatMsg) Chat.Message {
+	var commands []string
+	for command := range m.helpMap {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
 
-	for command, help := range m.helpMap {
-		responseText += fmt.Sprintf("%c%s  -  %s\n", commandPrefix, command, help)
+	var items [][]Chat.Span
+	for _, command := range commands {
+		items = append(items, []Chat.Span{Chat.Bold(fmt.Sprintf("%c%s", commandPrefix, command)), Chat.Plain("  -  " + m.helpMap[command])})
 	}
 
-	return responseText
+	var response Chat.Message
+	return response.Heading(Chat.Plain("Commands:")).List(items...)
 }
 
 func (m *CommandMap) AddCommand(commandName string, function commandFunc, helpText string) {
@@ -45,3 +61,74 @@ func (m *CommandMap) GetFunction(commandName string) (commandFunc, bool) {
 	function, pres := m.funcMap[commandName]
 	return function, pres
 }
+
+// Gives an existing command a form, used instead of the plain text response by chat backends that support forms
+func (m *CommandMap) AddForm(commandName string, form commandForm) {
+	m.formMap[commandName] = form
+}
+
+// Gets every command as a form command. Commands without a form run as if typed with no arguments and return their text,
+// with their ESI calls cancelled along with the context.
+func (m *CommandMap) FormCommands(ctx context.Context) []Chat.FormCommand {
+	var result []Chat.FormCommand
+
+	for command, help := range m.helpMap {
+		form, pres := m.formMap[command]
+		if !pres {
+			form = textForm(ctx, command, m.funcMap[command])
+		}
+
+		result = append(result, Chat.FormCommand{
+			Name:        command,
+			Description: help,
+			Fields:      form.fields,
+			Execute:     form.execute,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Wraps a text command so it can be run as a form command
+func textForm(ctx context.Context, command string, function commandFunc) commandForm {
+	return commandForm{
+		execute: func(sender string, values Chat.FormValues) Chat.FormResult {
+			msg := Chat.ChatMsg{
+				Sender: sender,
+				Type:   Chat.PrivateMessage,
+				Text:   fmt.Sprintf("%c%s", commandPrefix, command),
+			}
+
+			commandCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+			defer cancel()
+
+			return messageResult(function(commandCtx, msg))
+		},
+	}
+}
+
+// Converts a message into a form result, using the first table in the message as the result table
+func messageResult(message Chat.Message) Chat.FormResult {
+	var result Chat.FormResult
+	var text Chat.Message
+
+	for _, block := range message.Blocks {
+		if block.Kind != Chat.TableBlock || len(result.Columns) > 0 {
+			text.Blocks = append(text.Blocks, block)
+			continue
+		}
+
+		result.Columns = block.Columns
+		for _, row := range block.Rows {
+			var cells []string
+			for _, cell := range row {
+				cells = append(cells, Chat.SpansText([]Chat.Span{cell}))
+			}
+			result.Rows = append(result.Rows, cells)
+		}
+	}
+
+	result.Text = text.PlainText()
+	return result
+}
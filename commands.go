@@ -2,80 +2,187 @@ package main
 
 import (
 	Chat "IncursionBot/internal/ChatClient"
+	"IncursionBot/internal/ESI"
+	incursions "IncursionBot/internal/Incursions"
 	logging "IncursionBot/internal/Logging"
+	templates "IncursionBot/internal/Templates"
+	"context"
 	"fmt"
 	"strings"
 	"time"
 )
 
 // Respond with the amount of time the bot's been up
-func getUptime(msg Chat.ChatMsg) string {
+func getUptime(ctx context.Context, msg Chat.ChatMsg) Chat.Message {
 	currentUptime := time.Since(startTime).Truncate(time.Second)
-	msgText := fmt.Sprintf("Bot has been up for: %s", currentUptime)
+	var response Chat.Message
+	response = response.Paragraph(Chat.Plain("Bot has been up for: "), Chat.Bold(currentUptime.String()))
 
 	logging.Infof("Sending uptime in response to a message from %s", msg.Sender)
-	return msgText
+	return response
 }
 
-func printESIStatus(msg Chat.ChatMsg) string {
-	var status string
-	// if ESI.CheckESI() { status = "GOOD" } else { status = "BAD" }
-	msgText := fmt.Sprintf("Connection to ESI is %s", status)
+// Respond with ESI's health, how each endpoint the bot uses is doing and how old the incursion data is
+func printESIStatus(ctx context.Context, msg Chat.ChatMsg) Chat.Message {
+	health := esi.Health() // Checked every minute, so spamming the command doesn't hit ESI
+
+	var response Chat.Message
+	response = response.Heading(Chat.Plain("ESI is "), Chat.Bold(string(health.Level)))
+	if health.CheckedAt.IsZero() {
+		response = response.Paragraph(Chat.Plain("ESI hasn't been checked yet"))
+	} else if health.Server != nil {
+		response = response.Paragraph(Chat.Plain(fmt.Sprintf("Tranquility has %d players online, server version %s", health.Server.Players, health.Server.ServerVersion)))
+	} else {
+		response = response.Paragraph(Chat.Plain("Couldn't get the server status: " + health.Error))
+	}
+
+	universeStatus := "not loaded, looking systems up on ESI"
+	if graph := esi.Universe(); graph != nil {
+		summary := graph.Summary()
+		universeStatus = fmt.Sprintf("%d systems from %s %s, built %s", summary.Systems, summary.Source, summary.Version, timeAgo(summary.BuiltAt))
+	}
+
+	response = response.List(
+		[]Chat.Span{Chat.Bold("Last health check: "), Chat.Plain(timeAgo(health.CheckedAt))},
+		[]Chat.Span{Chat.Bold("Last incursion poll: "), Chat.Plain(timeAgo(incursionPolls.LastSuccess()))},
+		[]Chat.Span{Chat.Bold("Incursion data last changed: "), Chat.Plain(timeAgo(esi.IncursionsModified()))},
+		[]Chat.Span{Chat.Bold("Universe: "), Chat.Plain(universeStatus)},
+	)
+
+	var rows [][]Chat.Span
+	for _, route := range health.Routes {
+		rows = append(rows, []Chat.Span{
+			Chat.Plain(route.Endpoint),
+			Chat.Plain(routeStatus(route)),
+			Chat.Plain(route.AverageLatency.Round(time.Millisecond).String()),
+			Chat.Plain(fmt.Sprintf("%d/%d", route.Errors, route.Requests)),
+		})
+	}
+	if len(rows) > 0 {
+		response = response.Table([]string{"Endpoint", "Status", "Latency", "Errors"}, rows...)
+	}
+
+	if health.Contract != nil && health.Contract.Error != "" {
+		response = response.Paragraph(Chat.Plain("Couldn't check the swagger spec: " + health.Contract.Error))
+	} else if health.Contract != nil && len(health.Contract.Issues) > 0 {
+		var issues [][]Chat.Span
+		for _, issue := range health.Contract.Issues {
+			issues = append(issues, []Chat.Span{Chat.Plain(issue.Endpoint), Chat.Plain(issue.Field), Chat.Plain(issue.Problem)})
+		}
+		response = response.Paragraph(Chat.Bold("Swagger spec " + health.Contract.SwaggerVersion + " differs from what the bot expects"))
+		response = response.Table([]string{"Endpoint", "Field", "Problem"}, issues...)
+	}
+
 	logging.Infof("Sending ESI status in response to a message from %s", msg.Sender)
-	return msgText
+	return response
+}
+
+// Describes how an endpoint is doing, preferring the bot's own experience over ESI's meta status
+func routeStatus(route ESI.RouteHealth) string {
+	switch {
+	case route.Breaker == ESI.BreakerOpen:
+		return "failing, not being called"
+	case route.Breaker == ESI.BreakerHalfOpen:
+		return "failing, checking for recovery"
+	case route.MetaStatus != "":
+		return route.MetaStatus
+	case route.LastStatus == 0:
+		return "request failed"
+	default:
+		return fmt.Sprintf("ok (%d)", route.LastStatus)
+	}
+}
+
+// Formats a time as how long ago it was along with the EVE time
+func timeAgo(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	return fmt.Sprintf("%s ago (%s)", time.Since(t).Truncate(time.Second), t.UTC().Format(templates.EVETimeFormat))
 }
 
-func listIncursions(msg Chat.ChatMsg) string {
-	responseText := "\n"
-	incursions := incManager.GetIncursions()
-
-	for _, incursion := range incursions {
-		responseText += fmt.Sprintf("%s - Influence: %.2f%% - Status: %s - %d jumps, Despawn: %s \n",
-			incursion.ToString(),
-			incursion.Influence*100, // Convert to % for easier reading
-			incursion.State,
-			incursion.Distance,
-			incursion.TimeLeftString(timeFormat))
+func listIncursions(ctx context.Context, msg Chat.ChatMsg) Chat.Message {
+	var response Chat.Message
+	response = response.Heading(Chat.Plain("Current incursions"))
+
+	var items [][]Chat.Span
+	for _, incursion := range incManager.GetIncursions() {
+		items = append(items, []Chat.Span{Chat.Plain(renderMessage(templates.IncursionEntry, incursion))})
+	}
+
+	if len(items) == 0 {
+		response = response.Paragraph(Chat.Emphasis("No incursions"))
+	} else {
+		response = response.List(items...)
 	}
 
 	logging.Infof("Sending current incursions in response to a message from %s", msg.Sender)
-	return responseText
+	return response
 }
 
-func nextSpawn(msg Chat.ChatMsg) string {
+func nextSpawn(ctx context.Context, msg Chat.ChatMsg) Chat.Message {
+	var response Chat.Message
+	response = response.Heading(Chat.Plain("Next spawn windows")).List(
+		[]Chat.Span{Chat.Bold("Nullsec: "), Chat.Plain(templates.FormatPrediction(incManager.NextSpawn(incursions.NullSec)))},
+		[]Chat.Span{Chat.Bold("Lowsec: "), Chat.Plain(templates.FormatPrediction(incManager.NextSpawn(incursions.LowSec)))},
+	)
+
 	logging.Infof("Sending next spawn times in response to a message from %s", msg.Sender)
-	return incManager.NextSpawns()
+	return response
 }
 
-func waitlistInstructions(msg Chat.ChatMsg) string {
+func waitlistInstructions(ctx context.Context, msg Chat.ChatMsg) Chat.Message {
 	logging.Infof("Sending waitlist instructions in response to a message from %s", msg.Sender)
-	return `To join the waitlist, check that a fleet is actively running, then x up in the imperium.incursions channel in-game with the ships that you have.
-Do not join the waitlist if you are not deployed to the HQ system. Do not move yourself.`
+	return Chat.Text(`To join the waitlist, check that a fleet is actively running, then x up in the imperium.incursions channel in-game with the ships that you have.
+Do not join the waitlist if you are not deployed to the HQ system. Do not move yourself.`)
 }
 
-func printLayout(msg Chat.ChatMsg) string {
-	incursions := incManager.GetIncursions()
+func printLayout(ctx context.Context, msg Chat.ChatMsg) Chat.Message {
+	fields := strings.Fields(msg.Text)
+	if len(fields) < 2 {
+		return Chat.Text("Usage: !layout <staging system or constellation>")
+	}
 
-	name := strings.Fields(msg.Text)[1]
+	name := fields[1]
 	logging.Debugln(name)
 
-	for _, incursion := range incursions {
-		if incursion.Layout.StagingSystem.Name == name || incursion.Constellation.Name == name {
-			resultText := "\n"
+	incursion, found := findIncursion(name)
+	if !found {
+		return Chat.Text("No spawn found")
+	}
 
-			resultText += "Staging: " + incursion.Layout.StagingSystem.Name + "\n"
-			for _, vanguard := range incursion.Layout.VanguardSystems {
-				resultText += "Vanguard: " + vanguard.Name + "\n"
-			}
+	var rows [][]Chat.Span
+	for _, row := range layoutRows(incursion) {
+		rows = append(rows, []Chat.Span{Chat.Plain(row[0]), Chat.Link(row[1], templates.DotlanSystemLink(row[1]))})
+	}
 
-			for _, assault := range incursion.Layout.AssaultSystems {
-				resultText += "Assault: " + assault.Name + "\n"
-			}
+	var response Chat.Message
+	return response.Heading(Chat.Plain("Layout of "+incursion.Constellation.Name)).Table([]string{"Site", "System"}, rows...)
+}
 
-			resultText += "HQ: " + incursion.Layout.HQSystem.Name + "\n"
-			return resultText
+// Finds the current incursion with the given staging system or constellation
+func findIncursion(name string) (incursions.Incursion, bool) {
+	for _, incursion := range incManager.GetIncursions() {
+		if incursion.Layout.StagingSystem.Name == name || incursion.Constellation.Name == name {
+			return incursion, true
 		}
 	}
 
-	return "No spawn found"
+	return incursions.Incursion{}, false
+}
+
+// Lists the site type and system name of every system in the incursion's layout
+func layoutRows(incursion incursions.Incursion) [][]string {
+	rows := [][]string{{"Staging", incursion.Layout.StagingSystem.Name}}
+
+	for _, vanguard := range incursion.Layout.VanguardSystems {
+		rows = append(rows, []string{"Vanguard", vanguard.Name})
+	}
+
+	for _, assault := range incursion.Layout.AssaultSystems {
+		rows = append(rows, []string{"Assault", assault.Name})
+	}
+
+	return append(rows, []string{"HQ", incursion.Layout.HQSystem.Name})
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	notifications "IncursionBot/internal/Notifications"
+	"encoding/json"
+	"os"
+)
+
+// User-editable bot configuration, loaded from a JSON file
+type Config struct {
+	Templates    map[string]string   `json:"templates"`    // Message templates to use in place of the built-in ones, by name
+	Destinations []DestinationConfig `json:"destinations"` // Channels to send notifications to, defaults to the channel joined on start
+
+	SubscriptionsFile string `json:"subscriptionsFile"` // File to save users' direct message subscriptions to, kept in memory only if empty
+
+	HTTPAddress  string `json:"httpAddress"`  // Address to serve the web endpoints on, e.g. ":8080", disabled if empty
+	HistoryFile  string `json:"historyFile"`  // File to save the spawn history to, kept in memory only if empty
+	ActivityFile string `json:"activityFile"` // File to save the last two weeks of events to for the feeds and weekly summary, kept in memory only if empty
+
+	ESIBaseURL   string `json:"esiBaseUrl"`   // ESI to use in place of esi.evetech.net including the version, e.g. a caching proxy
+	ESIUserAgent string `json:"esiUserAgent"` // User agent sent to ESI, ideally with contact details
+	ESICacheDir  string `json:"esiCacheDir"`  // Directory to keep static ESI data in between restarts, kept in memory only if empty
+
+	UniverseFile   string `json:"universeFile"`   // File to save the universe graph to, built from ESI or the SDE if missing. Delete it to force a rebuild. Universe lookups go to ESI if neither is set.
+	UniverseMaxAge string `json:"universeMaxAge"` // Age after which the universe is built again, e.g. "720h", 30 days if empty and never if "0"
+	SDEPath        string `json:"sdePath"`        // JSON Lines Static Data Export, zipped or extracted, to build the universe from in place of ESI. A new version is imported on startup.
+
+	Webhooks            []WebhookConfig `json:"webhooks"`            // URLs to post events to as JSON
+	InfluenceThresholds []float64       `json:"influenceThresholds"` // Influence levels from 0 to 1 that send an influence event to webhooks when crossed
+}
+
+// Configuration for a URL receiving events as JSON
+type WebhookConfig struct {
+	URL      string                     `json:"url"`
+	Secret   string                     `json:"secret"`   // Key to sign requests with using HMAC-SHA256
+	Events   []notifications.EventKind  `json:"events"`   // Kinds of event to send, spawn, stateChange, influence and despawn if empty
+	Security []incursions.SecurityClass `json:"security"` // Security classes to send events for, all if empty
+	HomeOnly bool                       `json:"homeOnly"` // Only send events for home region incursions
+}
+
+// Configuration for a single channel receiving notifications
+type DestinationConfig struct {
+	Channel          string             `json:"channel"`
+	QuietHours       []QuietHoursConfig `json:"quietHours"`       // Windows during which notifications are held and sent as a digest afterwards
+	HomeSpawnsBypass bool               `json:"homeSpawnsBypass"` // Send new spawns in home regions even during quiet hours
+	Summaries        []SummaryConfig    `json:"summaries"`        // Scheduled summary posts
+	Reminders        bool               `json:"reminders"`        // Send reminders ahead of state changes and respawn windows
+	Subject          SubjectConfig      `json:"subject"`          // Keep the room subject updated with the current incursions
+}
+
+// Configuration for keeping a room's subject up to date
+type SubjectConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Template    string `json:"template"`    // Name of the template to render the subject with, defaults to subject
+	MinInterval string `json:"minInterval"` // Minimum time between subject changes, e.g. "10m"
+}
+
+// A summary posted to a destination on a schedule
+type SummaryConfig struct {
+	Type     string `json:"type"`     // Either daily or weekly
+	Schedule string `json:"schedule"` // Cron expression in EVE time, e.g. "0 11 * * *" for every downtime
+}
+
+// Start and end of a quiet hours window, as HH:MM in EVE time
+type QuietHoursConfig struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Loads the config from the given file. An empty file name returns the default config.
+func loadConfig(fileName string) (Config, error) {
+	config := Config{
+		InfluenceThresholds: []float64{.25, .5, .75},
+	}
+
+	if fileName == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+	return config, err
+}
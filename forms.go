@@ -0,0 +1,141 @@
+package main
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	templates "IncursionBot/internal/Templates"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field names used on the forms
+const (
+	constellationField string = "constellation"
+	eventsField        string = "events"
+	securityField      string = "security"
+	homeOnlyField      string = "homeOnly"
+)
+
+var subscriptions notifications.Subscriptions // Users that want events sent to them directly
+
+// Picks the constellation from the current incursions instead of having to type it
+var layoutForm = commandForm{
+	fields: func(sender string) []Chat.FormField {
+		field := Chat.FormField{Name: constellationField, Label: "Constellation", Type: Chat.ListField, Required: true}
+
+		for _, incursion := range incManager.GetIncursions() {
+			field.Options = append(field.Options, Chat.FormOption{
+				Label: fmt.Sprintf("%s (%s)", incursion.Constellation.Name, incursion.Region.Name),
+				Value: incursion.Constellation.Name,
+			})
+		}
+
+		return []Chat.FormField{field}
+	},
+	execute: func(sender string, values Chat.FormValues) Chat.FormResult {
+		name := values.Get(constellationField)
+		incursion, found := findIncursion(name)
+		if !found {
+			return Chat.FormResult{Text: "No spawn found"}
+		}
+
+		logging.Infof("Sending layout of %s in response to a form from %s", name, sender)
+		return Chat.FormResult{
+			Title:   "Layout of " + name,
+			Columns: []string{"Site", "System"},
+			Rows:    layoutRows(incursion),
+		}
+	},
+}
+
+// Shows the current incursions as a table
+var incursionsForm = commandForm{
+	execute: func(sender string, values Chat.FormValues) Chat.FormResult {
+		result := Chat.FormResult{
+			Title:   "Current incursions",
+			Columns: []string{"Incursion", "Influence", "Status", "Jumps", "Despawn"},
+		}
+
+		for _, incursion := range incManager.GetIncursions() {
+			result.Rows = append(result.Rows, []string{
+				renderMessage(templates.IncursionName, incursion),
+				fmt.Sprintf("%.2f%%", incursion.Influence*100),
+				string(incursion.State),
+				strconv.Itoa(incursion.Distance),
+				strings.TrimSpace(incursion.TimeLeftString(templates.EVETimeFormat)),
+			})
+		}
+
+		logging.Infof("Sending current incursions in response to a form from %s", sender)
+		return result
+	},
+}
+
+// Lets users choose which events are sent to them directly
+var subscriptionForm = Chat.FormCommand{
+	Name:        "subscribe",
+	Description: "Manage the incursion events sent to you directly",
+	Fields: func(sender string) []Chat.FormField {
+		current, _ := subscriptions.Get(sender)
+
+		events := Chat.FormField{Name: eventsField, Label: "Events", Type: Chat.MultiListField, Options: []Chat.FormOption{
+			{Label: "New spawns", Value: string(notifications.SpawnEvent)},
+			{Label: "State changes", Value: string(notifications.StateChangeEvent)},
+			{Label: "Despawns", Value: string(notifications.DespawnEvent)},
+			{Label: "Reminders", Value: string(notifications.ReminderEvent)},
+		}}
+		for _, kind := range current.Events {
+			events.Values = append(events.Values, string(kind))
+		}
+
+		security := Chat.FormField{Name: securityField, Label: "Security (all if none picked)", Type: Chat.MultiListField, Options: []Chat.FormOption{
+			{Label: "Nullsec", Value: string(incursions.NullSec)},
+			{Label: "Lowsec", Value: string(incursions.LowSec)},
+		}}
+		for _, class := range current.Security {
+			security.Values = append(security.Values, string(class))
+		}
+
+		homeOnly := Chat.FormField{Name: homeOnlyField, Label: "Home regions only", Type: Chat.BooleanField, Values: []string{strconv.FormatBool(current.HomeOnly)}}
+
+		return []Chat.FormField{events, security, homeOnly}
+	},
+	Execute: func(sender string, values Chat.FormValues) Chat.FormResult {
+		var sub notifications.Subscription
+		for _, kind := range values[eventsField] {
+			sub.Events = append(sub.Events, notifications.EventKind(kind))
+		}
+		for _, class := range values[securityField] {
+			sub.Security = append(sub.Security, incursions.SecurityClass(class))
+		}
+		sub.HomeOnly = values.Bool(homeOnlyField)
+
+		err := subscriptions.Set(sender, sub)
+		if err != nil {
+			logging.Errorf("Failed to save subscription for %s: %v", sender, err)
+			return Chat.FormResult{Text: "Failed to save your subscription"}
+		}
+
+		if len(sub.Events) == 0 {
+			logging.Infof("%s unsubscribed", sender)
+			return Chat.FormResult{Text: "You are no longer subscribed to any events"}
+		}
+
+		logging.Infof("%s subscribed to %v", sender, values[eventsField])
+		return Chat.FormResult{Text: "Subscription saved"}
+	},
+}
+
+// Offers the commands as forms if the chat backend supports them
+func setupFormCommands(ctx context.Context, client Chat.ChatServer) {
+	server, ok := client.(Chat.FormCommandServer)
+	if !ok {
+		return
+	}
+
+	server.SetFormCommands(append(commandsMap.FormCommands(ctx), subscriptionForm))
+}
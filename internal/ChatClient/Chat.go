@@ -1,5 +1,7 @@
 package Chat
 
+import "errors"
+
 type MessageType int
 
 const (
@@ -17,7 +19,37 @@ type ChatMsg struct {
 type ChatServer interface {
 	BroadcastToChannel(message string, channel string) error
 	BroadcastToDefaultChannel(message string) error
-	ReplyToMsg(message string, origMsg ChatMsg) error
+	ReplyToMsg(message Message, origMsg ChatMsg) error
 	SendToUser(message string, user string) error
 	GetNextChatMessage() (ChatMsg, error)
 }
+
+// Optionally implemented by a ChatServer that needs message templates formatted for its platform.
+// Returned templates override the built-in defaults by name.
+type TemplateOverrider interface {
+	TemplateOverrides() map[string]string
+}
+
+// Optionally implemented by a ChatServer that has to join a channel before it can send to it
+type ChannelJoiner interface {
+	JoinChannel(channel string) error
+}
+
+// Optionally implemented by a ChatServer whose channels have a subject/topic the bot can set. SetSubject returns
+// ErrSubjectDenied once the channel has refused to let the bot change it.
+type SubjectSetter interface {
+	SetSubject(channel string, subject string) error
+}
+
+var ErrSubjectDenied = errors.New("not allowed to change the subject of this room")
+
+// Status the bot shows to its contacts
+type Presence struct {
+	Away   bool   // True if the bot's data can't currently be trusted
+	Status string // Free text status message
+}
+
+// Optionally implemented by a ChatServer that can show the bot's presence to its contacts
+type PresenceSetter interface {
+	SetPresence(presence Presence) error
+}
@@ -0,0 +1,92 @@
+package console
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	logging "IncursionBot/internal/Logging"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const consoleUser string = "console" // Sender of every message typed into the console
+
+// Chat server that reads commands from an input, such as stdin, and writes everything it sends to an output
+type ConsoleConnection struct {
+	scanner *bufio.Scanner
+	outMut  sync.Mutex
+	out     io.Writer
+}
+
+func NewConsole(in io.Reader, out io.Writer) *ConsoleConnection {
+	return &ConsoleConnection{
+		scanner: bufio.NewScanner(in),
+		out:     out,
+	}
+}
+
+// Gets the next non-empty line from the input. Blocks forever once the input is closed.
+func (console *ConsoleConnection) GetNextChatMessage() (Chat.ChatMsg, error) {
+	for console.scanner.Scan() {
+		text := strings.TrimSpace(console.scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		return Chat.ChatMsg{
+			Sender: consoleUser,
+			Type:   Chat.PrivateMessage,
+			Text:   text,
+		}, nil
+	}
+
+	if err := console.scanner.Err(); err != nil {
+		return Chat.ChatMsg{}, err
+	}
+
+	logging.Infoln("Console input closed, no longer reading commands")
+	select {}
+}
+
+func (console *ConsoleConnection) BroadcastToChannel(message string, channel string) error {
+	return console.write(channel, message)
+}
+
+func (console *ConsoleConnection) BroadcastToDefaultChannel(message string) error {
+	return console.write("default", message)
+}
+
+func (console *ConsoleConnection) ReplyToMsg(message Chat.Message, origMsg Chat.ChatMsg) error {
+	return console.write("reply to "+origMsg.Sender, message.PlainText())
+}
+
+func (console *ConsoleConnection) SendToUser(message string, user string) error {
+	return console.write("to "+user, message)
+}
+
+func (console *ConsoleConnection) SetSubject(channel string, subject string) error {
+	return console.write("subject of "+channel, subject)
+}
+
+func (console *ConsoleConnection) SetPresence(presence Chat.Presence) error {
+	return console.write("presence", presenceText(presence))
+}
+
+func presenceText(presence Chat.Presence) string {
+	status := "available"
+	if presence.Away {
+		status = "away"
+	}
+
+	return fmt.Sprintf("%s: %s", status, presence.Status)
+}
+
+// Writes the message with a label saying where it would have gone
+func (console *ConsoleConnection) write(label string, message string) error {
+	console.outMut.Lock()
+	defer console.outMut.Unlock()
+
+	_, err := fmt.Fprintf(console.out, "[%s] %s\n", label, message)
+	return err
+}
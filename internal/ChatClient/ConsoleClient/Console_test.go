@@ -0,0 +1,86 @@
+package console
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleInput(t *testing.T) {
+	assert := assert.New(t)
+	testSubject := NewConsole(strings.NewReader("!help\n\n   \n  !layout Foo  \n"), &bytes.Buffer{})
+
+	msg, err := testSubject.GetNextChatMessage()
+	assert.NoError(err)
+	assert.Equal("!help", msg.Text)
+	assert.Equal(consoleUser, msg.Sender)
+
+	// Blank lines are skipped
+	msg, err = testSubject.GetNextChatMessage()
+	assert.NoError(err)
+	assert.Equal("!layout Foo", msg.Text)
+}
+
+func TestConsoleOutput(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	testSubject := NewConsole(strings.NewReader(""), &out)
+
+	testSubject.BroadcastToChannel("New incursion", "incursions")
+	testSubject.ReplyToMsg(Chat.Text("pong"), Chat.ChatMsg{Sender: consoleUser})
+	testSubject.SetPresence(Chat.Presence{Away: true, Status: "ESI down"})
+
+	assert.Equal("[incursions] New incursion\n[reply to console] pong\n[presence] away: ESI down\n", out.String())
+}
+
+type fakeServer struct {
+	Chat.ChatServer
+	sent int
+}
+
+func (server *fakeServer) GetNextChatMessage() (Chat.ChatMsg, error) {
+	return Chat.ChatMsg{Text: "!help"}, nil
+}
+
+func (server *fakeServer) BroadcastToChannel(message string, channel string) error {
+	server.sent++
+	return nil
+}
+
+type fakeFormServer struct {
+	fakeServer
+	commands []Chat.FormCommand
+}
+
+func (server *fakeFormServer) SetFormCommands(commands []Chat.FormCommand) {
+	server.commands = commands
+}
+
+func TestDryRun(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	server := &fakeServer{}
+	testSubject := NewDryRun(server, &out)
+
+	msg, err := testSubject.GetNextChatMessage()
+	assert.NoError(err)
+	assert.Equal("!help", msg.Text)
+
+	testSubject.BroadcastToChannel("New incursion", "incursions")
+	testSubject.SetSubject("incursions", "2 null")
+	assert.Zero(server.sent)
+	assert.Equal("[dry run: incursions] New incursion\n[dry run: subject of incursions] 2 null\n", out.String())
+	assert.Nil(testSubject.TemplateOverrides())
+
+	// Nothing to pass form commands on to
+	testSubject.SetFormCommands([]Chat.FormCommand{{Name: "uptime"}})
+
+	formServer := &fakeFormServer{}
+	NewDryRun(formServer, &out).SetFormCommands([]Chat.FormCommand{{Name: "uptime"}})
+	if assert.Len(formServer.commands, 1) {
+		assert.Equal("uptime", formServer.commands[0].Name)
+	}
+}
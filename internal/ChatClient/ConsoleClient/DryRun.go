@@ -0,0 +1,64 @@
+package console
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	"io"
+)
+
+// Wraps a real chat server so commands are still received from it, but everything that would be sent
+// is written to an output instead
+type DryRunConnection struct {
+	server  Chat.ChatServer
+	console *ConsoleConnection
+}
+
+func NewDryRun(server Chat.ChatServer, out io.Writer) *DryRunConnection {
+	return &DryRunConnection{
+		server:  server,
+		console: NewConsole(nil, out),
+	}
+}
+
+func (dryRun *DryRunConnection) GetNextChatMessage() (Chat.ChatMsg, error) {
+	return dryRun.server.GetNextChatMessage()
+}
+
+func (dryRun *DryRunConnection) BroadcastToChannel(message string, channel string) error {
+	return dryRun.console.write("dry run: "+channel, message)
+}
+
+func (dryRun *DryRunConnection) BroadcastToDefaultChannel(message string) error {
+	return dryRun.console.write("dry run: default", message)
+}
+
+func (dryRun *DryRunConnection) ReplyToMsg(message Chat.Message, origMsg Chat.ChatMsg) error {
+	return dryRun.console.write("dry run: reply to "+origMsg.Sender, message.PlainText())
+}
+
+func (dryRun *DryRunConnection) SendToUser(message string, user string) error {
+	return dryRun.console.write("dry run: to "+user, message)
+}
+
+func (dryRun *DryRunConnection) SetSubject(channel string, subject string) error {
+	return dryRun.console.write("dry run: subject of "+channel, subject)
+}
+
+func (dryRun *DryRunConnection) SetPresence(presence Chat.Presence) error {
+	return dryRun.console.write("dry run: presence", presenceText(presence))
+}
+
+// Keeps rendering messages the way the real server would
+func (dryRun *DryRunConnection) TemplateOverrides() map[string]string {
+	if overrider, ok := dryRun.server.(Chat.TemplateOverrider); ok {
+		return overrider.TemplateOverrides()
+	}
+
+	return nil
+}
+
+// Forms are answered by the real server, as replies to whoever filled them in
+func (dryRun *DryRunConnection) SetFormCommands(commands []Chat.FormCommand) {
+	if server, ok := dryRun.server.(Chat.FormCommandServer); ok {
+		server.SetFormCommands(commands)
+	}
+}
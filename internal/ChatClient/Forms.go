@@ -0,0 +1,64 @@
+package Chat
+
+type FieldType string
+
+const (
+	TextField      FieldType = "text-single"
+	ListField      FieldType = "list-single"
+	MultiListField FieldType = "list-multi"
+	BooleanField   FieldType = "boolean"
+)
+
+type FormOption struct {
+	Label string
+	Value string
+}
+
+// Single input on a form
+type FormField struct {
+	Name     string // Identifies the field in the submitted values
+	Label    string
+	Type     FieldType
+	Options  []FormOption // Choices for list fields
+	Values   []string     // Initial values
+	Required bool
+}
+
+// Values submitted on a form, by field name
+type FormValues map[string][]string
+
+// Gets the first value submitted for the field, or an empty string if there wasn't one
+func (values FormValues) Get(name string) string {
+	if len(values[name]) == 0 {
+		return ""
+	}
+
+	return values[name][0]
+}
+
+// Checks if a boolean field was ticked
+func (values FormValues) Bool(name string) bool {
+	value := values.Get(name)
+	return value == "1" || value == "true"
+}
+
+// Result of a form command, either a text note, a table, or both
+type FormResult struct {
+	Title   string
+	Text    string
+	Columns []string
+	Rows    [][]string
+}
+
+// Command that chat backends with structured form support can offer alongside the text commands
+type FormCommand struct {
+	Name        string                          // Identifies the command
+	Description string                          // Human readable name shown in clients
+	Fields      func(sender string) []FormField // Input to ask for before executing, nil if there is none
+	Execute     func(sender string, values FormValues) FormResult
+}
+
+// Optionally implemented by a ChatServer that can offer form commands
+type FormCommandServer interface {
+	SetFormCommands(commands []FormCommand)
+}
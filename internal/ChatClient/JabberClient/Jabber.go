@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/mattn/go-xmpp"
@@ -18,25 +20,37 @@ type JabberConnection struct {
 	password string
 	nickname string
 	client   *xmpp.Client
+
+	channelMut sync.Mutex
+	channels   []string // MUCs joined besides the configured channel, joined again after reconnecting
+
+	permissionMut sync.Mutex
+	subjectDenied map[string]bool // MUCs that refused to let the bot change the subject
+
+	presenceMut sync.Mutex
+	presence    *Chat.Presence // Last presence set, restored after reconnecting
+
+	adhoc adhocCommands
 }
 
 const retryDuration = time.Minute // Time to wait between reconnect attempts
 
 // Create a new jabber connection
-func CreateNewJabberConnection(server string, channel string, username string, password string, nickname string) (JabberConnection, error) {
-	newServer := JabberConnection{
-		server:   server,
-		channel:  channel,
-		username: username,
-		password: password,
-		nickname: nickname,
+func CreateNewJabberConnection(server string, channel string, username string, password string, nickname string) (*JabberConnection, error) {
+	newServer := &JabberConnection{
+		server:        server,
+		channel:       channel,
+		username:      username,
+		password:      password,
+		nickname:      nickname,
+		subjectDenied: make(map[string]bool),
 	}
 
 	err := newServer.ConnectToChannel()
 	return newServer, err
 }
 
-// Connect to the configured server and the configured channel
+// Connect to the configured server and join the configured channel along with any others joined since
 func (conn *JabberConnection) ConnectToChannel() error {
 	var err error
 	logging.Infof("Connecting to %s...", conn.server)
@@ -50,13 +64,58 @@ func (conn *JabberConnection) ConnectToChannel() error {
 		return errors.New("Server did not promote connection to TLS")
 	}
 
-	mucJID := fmt.Sprintf("%s@%s", conn.channel, conn.server)
-	logging.Infof("Joining %s as %s", mucJID, conn.nickname)
-	_, err = conn.client.JoinMUCNoHistory(mucJID, conn.nickname)
+	err = conn.joinMUC(conn.channel)
+	if err != nil {
+		return err
+	}
+
+	conn.channelMut.Lock()
+	for _, channel := range conn.channels {
+		err = conn.joinMUC(channel)
+		if err != nil {
+			conn.channelMut.Unlock()
+			return err
+		}
+	}
+	conn.channelMut.Unlock()
+
+	conn.presenceMut.Lock()
+	defer conn.presenceMut.Unlock()
+	if conn.presence != nil {
+		err = conn.sendPresence(*conn.presence)
+	}
 
 	return err
 }
 
+// Joins another MUC on the server so messages can be sent to it, rejoining it whenever the connection is restored
+func (conn *JabberConnection) JoinChannel(channel string) error {
+	if channel == conn.channel {
+		return nil
+	}
+
+	conn.channelMut.Lock()
+	defer conn.channelMut.Unlock()
+	if slices.Contains(conn.channels, channel) {
+		return nil
+	}
+
+	err := conn.joinMUC(channel)
+	if err != nil {
+		return err
+	}
+
+	conn.channels = append(conn.channels, channel)
+	return nil
+}
+
+func (conn *JabberConnection) joinMUC(channel string) error {
+	mucJID := fmt.Sprintf("%s@%s", channel, conn.server)
+	logging.Infof("Joining %s as %s", mucJID, conn.nickname)
+	_, err := conn.client.JoinMUCNoHistory(mucJID, conn.nickname)
+	return err
+}
+
 // Tries to reconnect to the configured server in case of a disconnect
 // TODO: Add exponential backoff?
 func (comm *JabberConnection) reconnectLoop() {
@@ -89,7 +148,17 @@ func (comm *JabberConnection) GetNextChatMessage() (Chat.ChatMsg, error) {
 			return Chat.ChatMsg{}, err // Something weird happened, pass up to someone else to handle
 		}
 
+		if iq, ok := msg.(xmpp.IQ); ok {
+			comm.handleIQ(iq)
+			continue
+		}
+
 		chatMsg, ok := msg.(xmpp.Chat)
+		if ok && chatMsg.Type == errorMessage && chatMsg.Subject != "" {
+			comm.denySubject(chatMsg.Remote)
+			continue
+		}
+
 		if !ok || len(chatMsg.Text) == 0 {
 			continue
 		} // Not a valid chat message
@@ -102,16 +171,22 @@ func (comm *JabberConnection) GetNextChatMessage() (Chat.ChatMsg, error) {
 	}
 }
 
-func (conn *JabberConnection) ReplyToMsg(message string, origMsg Chat.ChatMsg) error {
-	msg := conn.createReply(origMsg, message)
+// Replies with the message, using XHTML-IM if it has any formatting
+func (conn *JabberConnection) ReplyToMsg(message Chat.Message, origMsg Chat.ChatMsg) error {
+	msg := conn.createReply(origMsg, plainBody(message))
 
-	_, err := conn.client.Send(msg)
+	if message.IsPlain() {
+		_, err := conn.client.Send(msg)
+		return err
+	}
+
+	_, err := conn.client.SendOrg(createRichStanza(msg.Remote, msg.Type, msg.Text, renderXHTML(message)))
 	return err
 }
 
-// TODO: Add more than one channel it can be in
+// Sends the message to a MUC, which must be the configured channel or one joined with JoinChannel
 func (conn *JabberConnection) BroadcastToChannel(message string, channel string) error {
-	msg := conn.newGroupMessage(message, channel)
+	msg := conn.newGroupMessage(channel, message)
 
 	_, err := conn.client.Send(msg)
 	return err
@@ -135,6 +210,55 @@ func (conn *JabberConnection) SendToUser(message string, user string) error {
 	return err
 }
 
+// Sets the subject of the given MUC. Returns Chat.ErrSubjectDenied if the room has previously refused a subject change.
+func (conn *JabberConnection) SetSubject(channel string, subject string) error {
+	muc := fmt.Sprintf("%s@%s", channel, conn.server)
+
+	conn.permissionMut.Lock()
+	denied := conn.subjectDenied[muc]
+	conn.permissionMut.Unlock()
+	if denied {
+		return Chat.ErrSubjectDenied
+	}
+
+	_, err := conn.client.SendTopic(xmpp.Chat{
+		Remote: muc,
+		Type:   conferenceChat,
+		Text:   subject,
+	})
+	return err
+}
+
+// Publishes the bot's presence to its roster contacts
+func (conn *JabberConnection) SetPresence(presence Chat.Presence) error {
+	conn.presenceMut.Lock()
+	defer conn.presenceMut.Unlock()
+
+	conn.presence = &presence
+	return conn.sendPresence(presence)
+}
+
+// Must be called with presenceMut locked
+func (conn *JabberConnection) sendPresence(presence Chat.Presence) error {
+	_, err := conn.client.SendOrg(createPresence(presence))
+	return err
+}
+
+// The room rejected a subject change, most likely because the bot isn't a moderator
+func (conn *JabberConnection) denySubject(jid string) {
+	muc := parseMuc(jid, conn.server)
+	logging.Warningf("Not allowed to change the subject of %s, no longer attempting to", muc)
+
+	conn.permissionMut.Lock()
+	conn.subjectDenied[muc] = true
+	conn.permissionMut.Unlock()
+}
+
+// Jabber clients display the built-in plain text templates as-is, so there is nothing to override
+func (conn *JabberConnection) TemplateOverrides() map[string]string {
+	return map[string]string{}
+}
+
 func parseMsgType(msg xmpp.Chat) Chat.MessageType {
 	switch msg.Type {
 	case privateMessage:
@@ -0,0 +1,379 @@
+package jabber
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	logging "IncursionBot/internal/Logging"
+	"encoding/xml"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-xmpp"
+)
+
+// Ad-hoc commands (XEP-0050) with data forms (XEP-0004)
+const (
+	nsCommands  string = "http://jabber.org/protocol/commands"
+	nsDataForms string = "jabber:x:data"
+	nsDiscoInfo string = "http://jabber.org/protocol/disco#info"
+	nsDiscoItem string = "http://jabber.org/protocol/disco#items"
+	nsPing      string = "urn:xmpp:ping"
+	nsStanzas   string = "urn:ietf:params:xml:ns:xmpp-stanzas"
+)
+
+const sessionTimeout time.Duration = time.Minute * 10 // Forms left unsubmitted for longer than this are forgotten
+
+type adhocCommand struct {
+	XMLName   xml.Name   `xml:"http://jabber.org/protocol/commands command"`
+	Node      string     `xml:"node,attr"`
+	SessionID string     `xml:"sessionid,attr,omitempty"`
+	Action    string     `xml:"action,attr,omitempty"`
+	Status    string     `xml:"status,attr,omitempty"`
+	Note      *adhocNote `xml:"note,omitempty"`
+	Form      *dataForm  `xml:"jabber:x:data x,omitempty"`
+}
+
+type adhocNote struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type dataForm struct {
+	XMLName      xml.Name      `xml:"jabber:x:data x"`
+	Type         string        `xml:"type,attr"`
+	Title        string        `xml:"title,omitempty"`
+	Instructions string        `xml:"instructions,omitempty"`
+	Fields       []formField   `xml:"field"`
+	Reported     *formReported `xml:"reported,omitempty"`
+	Items        []formItem    `xml:"item"`
+}
+
+type formField struct {
+	Var      string       `xml:"var,attr,omitempty"`
+	Label    string       `xml:"label,attr,omitempty"`
+	Type     string       `xml:"type,attr,omitempty"`
+	Required *struct{}    `xml:"required,omitempty"`
+	Values   []string     `xml:"value"`
+	Options  []formOption `xml:"option"`
+}
+
+type formOption struct {
+	Label string `xml:"label,attr,omitempty"`
+	Value string `xml:"value"`
+}
+
+type formReported struct {
+	Fields []formField `xml:"field"`
+}
+
+type formItem struct {
+	Fields []formField `xml:"field"`
+}
+
+// Minimal view of any IQ payload, enough to route it
+type iqQuery struct {
+	XMLName xml.Name
+	Node    string `xml:"node,attr"`
+}
+
+type discoIdentity struct {
+	Category string `xml:"category,attr"`
+	Type     string `xml:"type,attr"`
+	Name     string `xml:"name,attr,omitempty"`
+}
+
+type discoFeature struct {
+	Var string `xml:"var,attr"`
+}
+
+type discoInfo struct {
+	XMLName    xml.Name        `xml:"http://jabber.org/protocol/disco#info query"`
+	Node       string          `xml:"node,attr,omitempty"`
+	Identities []discoIdentity `xml:"identity"`
+	Features   []discoFeature  `xml:"feature"`
+}
+
+type discoItem struct {
+	JID  string `xml:"jid,attr"`
+	Node string `xml:"node,attr"`
+	Name string `xml:"name,attr"`
+}
+
+type discoItems struct {
+	XMLName xml.Name    `xml:"http://jabber.org/protocol/disco#items query"`
+	Node    string      `xml:"node,attr,omitempty"`
+	Items   []discoItem `xml:"item"`
+}
+
+// Form commands offered to clients and the sessions of forms waiting to be submitted
+type adhocCommands struct {
+	mut         sync.Mutex
+	commands    []Chat.FormCommand
+	sessions    map[string]adhocSession
+	lastSession int
+}
+
+type adhocSession struct {
+	node    string
+	sender  string
+	created time.Time
+}
+
+// Offers the given commands to XMPP clients as ad-hoc commands
+func (conn *JabberConnection) SetFormCommands(commands []Chat.FormCommand) {
+	conn.adhoc.mut.Lock()
+	defer conn.adhoc.mut.Unlock()
+
+	conn.adhoc.commands = commands
+}
+
+// Answers an IQ request, only get and set requests need a response
+func (conn *JabberConnection) handleIQ(iq xmpp.IQ) {
+	if iq.Type != xmpp.IQTypeGet && iq.Type != xmpp.IQTypeSet {
+		return
+	}
+
+	var query iqQuery
+	if len(iq.Query) > 0 {
+		err := xml.Unmarshal(iq.Query, &query)
+		if err != nil {
+			logging.Warningf("Failed to parse IQ from %s: %v", iq.From, err)
+			conn.sendIQError(iq, "bad-request")
+			return
+		}
+	}
+
+	var response any
+	switch {
+	case query.XMLName.Space == nsPing:
+		return // Already answered by go-xmpp
+	case query.XMLName.Space == nsDiscoInfo && iq.Type == xmpp.IQTypeGet:
+		response = conn.adhoc.discoInfo(query.Node)
+	case query.XMLName.Space == nsDiscoItem && iq.Type == xmpp.IQTypeGet && query.Node == nsCommands:
+		response = conn.adhoc.discoItems(iq.To)
+	case query.XMLName.Space == nsCommands && iq.Type == xmpp.IQTypeSet:
+		var request adhocCommand
+		err := xml.Unmarshal(iq.Query, &request)
+		if err != nil {
+			conn.sendIQError(iq, "bad-request")
+			return
+		}
+
+		// Commands can take a while, so run them off the receive loop
+		go conn.runCommand(iq, request)
+		return
+	default:
+		conn.sendIQError(iq, "service-unavailable")
+		return
+	}
+
+	conn.sendIQResult(iq, response)
+}
+
+func (conn *JabberConnection) runCommand(iq xmpp.IQ, request adhocCommand) {
+	response, errCondition := conn.adhoc.execute(commandSender(iq.From, conn.server), request)
+	if errCondition != "" {
+		conn.sendIQError(iq, errCondition)
+		return
+	}
+
+	conn.sendIQResult(iq, response)
+}
+
+func (conn *JabberConnection) sendIQResult(iq xmpp.IQ, response any) {
+	body, err := xml.Marshal(response)
+	if err != nil {
+		logging.Errorln("Failed to create IQ response", err)
+		return
+	}
+
+	_, err = conn.client.RawInformation(iq.To, iq.From, iq.ID, xmpp.IQTypeResult, string(body))
+	if err != nil {
+		logging.Errorln("Failed to send IQ response", err)
+	}
+}
+
+func (conn *JabberConnection) sendIQError(iq xmpp.IQ, condition string) {
+	body := "<error type='cancel'><" + condition + " xmlns='" + nsStanzas + "'/></error>"
+
+	_, err := conn.client.RawInformation(iq.To, iq.From, iq.ID, "error", body)
+	if err != nil {
+		logging.Errorln("Failed to send IQ error", err)
+	}
+}
+
+// Advertises support for ad-hoc commands, or describes a single command node
+func (adhoc *adhocCommands) discoInfo(node string) discoInfo {
+	info := discoInfo{Node: node}
+
+	if node == "" {
+		info.Identities = []discoIdentity{{Category: "client", Type: "bot", Name: "IncursionBot"}}
+		info.Features = []discoFeature{{nsDiscoInfo}, {nsDiscoItem}, {nsCommands}, {nsDataForms}}
+		return info
+	}
+
+	info.Identities = []discoIdentity{{Category: "automation", Type: "command-node"}}
+	info.Features = []discoFeature{{nsCommands}, {nsDataForms}}
+	return info
+}
+
+// Lists the available commands
+func (adhoc *adhocCommands) discoItems(ownJID string) discoItems {
+	adhoc.mut.Lock()
+	defer adhoc.mut.Unlock()
+
+	items := discoItems{Node: nsCommands}
+	for _, command := range adhoc.commands {
+		items.Items = append(items.Items, discoItem{JID: ownJID, Node: command.Name, Name: command.Description})
+	}
+
+	return items
+}
+
+// Runs one stage of a command, returning either the response or an XMPP error condition. The command runs without
+// holding the lock, so a slow one doesn't hold up any other session.
+func (adhoc *adhocCommands) execute(sender string, request adhocCommand) (adhocCommand, string) {
+	command, sessionID, errCondition := adhoc.claim(sender, request)
+	if errCondition != "" {
+		return adhocCommand{}, errCondition
+	}
+
+	response := adhocCommand{Node: request.Node, SessionID: request.SessionID}
+
+	if request.Action == "cancel" {
+		response.Status = "canceled"
+		return response, ""
+	}
+
+	if sessionID != "" {
+		// First stage, ask for input
+		response.SessionID = sessionID
+		response.Status = "executing"
+		response.Form = createInputForm(command, command.Fields(sender))
+		return response, ""
+	}
+
+	values := make(Chat.FormValues)
+	if request.Form != nil {
+		for _, field := range request.Form.Fields {
+			values[field.Var] = field.Values
+		}
+	}
+
+	logging.Infof("Running ad-hoc command %s for %s", command.Name, sender)
+	response.Status = "completed"
+	result := command.Execute(sender, values)
+	response.Form, response.Note = createResultForm(result)
+	return response, ""
+}
+
+// Finds the command and updates the sessions for this stage. Cancelling forgets the session, a first stage needing input
+// starts one, and submitting a form claims its session so it can only run once. Returns the new session's ID, if any.
+func (adhoc *adhocCommands) claim(sender string, request adhocCommand) (Chat.FormCommand, string, string) {
+	adhoc.mut.Lock()
+	defer adhoc.mut.Unlock()
+
+	command, found := adhoc.find(request.Node)
+	if !found {
+		return command, "", "item-not-found"
+	}
+
+	if request.Action == "cancel" {
+		delete(adhoc.sessions, request.SessionID)
+		return command, "", ""
+	}
+
+	if request.SessionID == "" {
+		if command.Fields != nil {
+			return command, adhoc.newSession(request.Node, sender), ""
+		}
+		return command, "", ""
+	}
+
+	session, pres := adhoc.sessions[request.SessionID]
+	if !pres || session.node != request.Node || session.sender != sender {
+		return command, "", "bad-request"
+	}
+	delete(adhoc.sessions, request.SessionID)
+	return command, "", ""
+}
+
+// Must be called with mut locked
+func (adhoc *adhocCommands) find(node string) (Chat.FormCommand, bool) {
+	for _, command := range adhoc.commands {
+		if command.Name == node {
+			return command, true
+		}
+	}
+
+	return Chat.FormCommand{}, false
+}
+
+// Starts a session for a form waiting to be submitted, forgetting any abandoned ones. Must be called with mut locked.
+func (adhoc *adhocCommands) newSession(node string, sender string) string {
+	if adhoc.sessions == nil {
+		adhoc.sessions = make(map[string]adhocSession)
+	}
+
+	for id, session := range adhoc.sessions {
+		if time.Since(session.created) > sessionTimeout {
+			delete(adhoc.sessions, id)
+		}
+	}
+
+	adhoc.lastSession++
+	id := strconv.Itoa(adhoc.lastSession)
+	adhoc.sessions[id] = adhocSession{node: node, sender: sender, created: time.Now()}
+	return id
+}
+
+func createInputForm(command Chat.FormCommand, fields []Chat.FormField) *dataForm {
+	form := &dataForm{Type: "form", Title: command.Description}
+
+	for _, field := range fields {
+		newField := formField{
+			Var:    field.Name,
+			Label:  field.Label,
+			Type:   string(field.Type),
+			Values: field.Values,
+		}
+
+		if field.Required {
+			newField.Required = &struct{}{}
+		}
+
+		for _, option := range field.Options {
+			newField.Options = append(newField.Options, formOption(option))
+		}
+
+		form.Fields = append(form.Fields, newField)
+	}
+
+	return form
+}
+
+// Converts a result into a result form, using the reported/item table layout for tabular results
+func createResultForm(result Chat.FormResult) (*dataForm, *adhocNote) {
+	if len(result.Columns) == 0 {
+		return nil, &adhocNote{Type: "info", Text: result.Text}
+	}
+
+	form := &dataForm{Type: "result", Title: result.Title, Instructions: result.Text, Reported: &formReported{}}
+	for i, column := range result.Columns {
+		form.Reported.Fields = append(form.Reported.Fields, formField{Var: columnVar(i), Label: column})
+	}
+
+	for _, row := range result.Rows {
+		var item formItem
+		for i, value := range row {
+			item.Fields = append(item.Fields, formField{Var: columnVar(i), Values: []string{value}})
+		}
+		form.Items = append(form.Items, item)
+	}
+
+	return form, nil
+}
+
+func columnVar(i int) string {
+	return "column" + strconv.Itoa(i)
+}
@@ -0,0 +1,161 @@
+package jabber
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	logging "IncursionBot/internal/Logging"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCommands() []Chat.FormCommand {
+	return []Chat.FormCommand{
+		{
+			Name:        "uptime",
+			Description: "Uptime",
+			Execute: func(sender string, values Chat.FormValues) Chat.FormResult {
+				return Chat.FormResult{Text: "up"}
+			},
+		},
+		{
+			Name:        "layout",
+			Description: "Layout",
+			Fields: func(sender string) []Chat.FormField {
+				return []Chat.FormField{{Name: "constellation", Type: Chat.ListField, Required: true,
+					Options: []Chat.FormOption{{Label: "A", Value: "a"}}}}
+			},
+			Execute: func(sender string, values Chat.FormValues) Chat.FormResult {
+				return Chat.FormResult{Columns: []string{"Role", "System"}, Rows: [][]string{{"HQ", values.Get("constellation")}}}
+			},
+		},
+	}
+}
+
+func TestAdhocDisco(t *testing.T) {
+	assert := assert.New(t)
+	var testSubject adhocCommands
+	testSubject.commands = testCommands()
+
+	items := testSubject.discoItems("bot@test.com/res")
+	assert.Len(items.Items, 2)
+	assert.Equal("layout", items.Items[1].Node)
+	assert.Equal("bot@test.com/res", items.Items[1].JID)
+
+	info := testSubject.discoInfo("")
+	assert.Contains(info.Features, discoFeature{nsCommands})
+}
+
+func TestAdhocExecute(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	var testSubject adhocCommands
+	testSubject.commands = testCommands()
+
+	t.Run("Single stage", func(t *testing.T) {
+		response, errCondition := testSubject.execute("user@test.com", adhocCommand{Node: "uptime", Action: "execute"})
+		assert.Empty(errCondition)
+		assert.Equal("completed", response.Status)
+		assert.Equal("up", response.Note.Text)
+		assert.Nil(response.Form)
+	})
+
+	t.Run("Form", func(t *testing.T) {
+		response, errCondition := testSubject.execute("user@test.com", adhocCommand{Node: "layout"})
+		assert.Empty(errCondition)
+		assert.Equal("executing", response.Status)
+		assert.NotEmpty(response.SessionID)
+		assert.Equal("form", response.Form.Type)
+		assert.Equal("constellation", response.Form.Fields[0].Var)
+		assert.NotNil(response.Form.Fields[0].Required)
+
+		// Someone else can't submit the form
+		_, errCondition = testSubject.execute("other@test.com", adhocCommand{Node: "layout", SessionID: response.SessionID})
+		assert.Equal("bad-request", errCondition)
+
+		submit := adhocCommand{
+			Node:      "layout",
+			SessionID: response.SessionID,
+			Form:      &dataForm{Type: "submit", Fields: []formField{{Var: "constellation", Values: []string{"a"}}}},
+		}
+		response, errCondition = testSubject.execute("user@test.com", submit)
+		assert.Empty(errCondition)
+		assert.Equal("completed", response.Status)
+		assert.Equal("result", response.Form.Type)
+		assert.Equal("System", response.Form.Reported.Fields[1].Label)
+		assert.Equal([]string{"a"}, response.Form.Items[0].Fields[1].Values)
+
+		// Session is finished
+		_, errCondition = testSubject.execute("user@test.com", submit)
+		assert.Equal("bad-request", errCondition)
+	})
+
+	t.Run("Room occupants", func(t *testing.T) {
+		server := "conference.test.com"
+		response, _ := testSubject.execute(commandSender("room@"+server+"/alice", server), adhocCommand{Node: "layout"})
+
+		// Another occupant of the same room can't submit the form
+		_, errCondition := testSubject.execute(commandSender("room@"+server+"/bob", server), adhocCommand{Node: "layout", SessionID: response.SessionID})
+		assert.Equal("bad-request", errCondition)
+
+		response, errCondition = testSubject.execute(commandSender("room@"+server+"/alice", server), adhocCommand{Node: "layout", SessionID: response.SessionID, Action: "cancel"})
+		assert.Empty(errCondition)
+		assert.Equal("canceled", response.Status)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		response, _ := testSubject.execute("user@test.com", adhocCommand{Node: "layout"})
+		response, errCondition := testSubject.execute("user@test.com", adhocCommand{Node: "layout", SessionID: response.SessionID, Action: "cancel"})
+		assert.Empty(errCondition)
+		assert.Equal("canceled", response.Status)
+		assert.Empty(testSubject.sessions)
+	})
+
+	t.Run("Unknown command", func(t *testing.T) {
+		_, errCondition := testSubject.execute("user@test.com", adhocCommand{Node: "missing"})
+		assert.Equal("item-not-found", errCondition)
+	})
+
+	t.Run("Slow command", func(t *testing.T) {
+		release := make(chan struct{})
+		testSubject.commands = append(testCommands(), Chat.FormCommand{
+			Name: "slow",
+			Execute: func(sender string, values Chat.FormValues) Chat.FormResult {
+				<-release
+				return Chat.FormResult{Text: "done"}
+			},
+		})
+
+		finished := make(chan adhocCommand)
+		go func() {
+			response, _ := testSubject.execute("user@test.com", adhocCommand{Node: "slow"})
+			finished <- response
+		}()
+
+		// Other commands still run while it is going
+		response, errCondition := testSubject.execute("other@test.com", adhocCommand{Node: "uptime"})
+		assert.Empty(errCondition)
+		assert.Equal("up", response.Note.Text)
+
+		close(release)
+		assert.Equal("done", (<-finished).Note.Text)
+	})
+}
+
+func TestAdhocParsing(t *testing.T) {
+	assert := assert.New(t)
+
+	// go-xmpp hands back the query with the namespace declared twice
+	raw := `<command xmlns="http://jabber.org/protocol/commands" xmlns="http://jabber.org/protocol/commands" node="layout" sessionid="1">` +
+		`<x xmlns="jabber:x:data" type="submit"><field var="constellation"><value>a</value></field></x></command>`
+
+	var request adhocCommand
+	assert.NoError(xml.Unmarshal([]byte(raw), &request))
+	assert.Equal("layout", request.Node)
+	assert.Equal("1", request.SessionID)
+	assert.Equal([]string{"a"}, request.Form.Fields[0].Values)
+
+	var query iqQuery
+	assert.NoError(xml.Unmarshal([]byte(raw), &query))
+	assert.Equal(nsCommands, query.XMLName.Space)
+}
@@ -0,0 +1,124 @@
+package jabber
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	"encoding/xml"
+	"strings"
+)
+
+// Rich messages are sent as XHTML-IM (XEP-0071), with a plain text body for clients that don't support it
+const (
+	nsXHTMLIM string = "http://jabber.org/protocol/xhtml-im"
+	nsXHTML   string = "http://www.w3.org/1999/xhtml"
+)
+
+// Renders the message using the elements recommended for XHTML-IM. Tables aren't part of the recommended
+// set, so they are rendered as lines of text with a bold header.
+func renderXHTML(msg Chat.Message) string {
+	var result strings.Builder
+
+	for _, block := range msg.Blocks {
+		switch block.Kind {
+		case Chat.HeadingBlock:
+			result.WriteString("<p><strong>")
+			writeSpans(&result, block.Spans)
+			result.WriteString("</strong></p>")
+		case Chat.ParagraphBlock:
+			result.WriteString("<p>")
+			writeSpans(&result, block.Spans)
+			result.WriteString("</p>")
+		case Chat.ListBlock:
+			result.WriteString("<ul>")
+			for _, item := range block.Items {
+				result.WriteString("<li>")
+				writeSpans(&result, item)
+				result.WriteString("</li>")
+			}
+			result.WriteString("</ul>")
+		case Chat.TableBlock:
+			result.WriteString("<p><strong>")
+			writeEscaped(&result, strings.Join(block.Columns, " | "))
+			result.WriteString("</strong>")
+			for _, row := range block.Rows {
+				result.WriteString("<br/>")
+				for i, cell := range row {
+					if i > 0 {
+						result.WriteString(" | ")
+					}
+					writeSpans(&result, []Chat.Span{cell})
+				}
+			}
+			result.WriteString("</p>")
+		}
+	}
+
+	return result.String()
+}
+
+func writeSpans(result *strings.Builder, spans []Chat.Span) {
+	for _, span := range spans {
+		if span.Link != "" {
+			result.WriteString("<a href='")
+			writeEscaped(result, span.Link)
+			result.WriteString("'>")
+		}
+
+		switch span.Style {
+		case Chat.BoldStyle:
+			result.WriteString("<strong>")
+		case Chat.EmphasisStyle:
+			result.WriteString("<em>")
+		}
+
+		lines := strings.Split(span.Text, "\n")
+		for i, line := range lines {
+			if i > 0 {
+				result.WriteString("<br/>")
+			}
+			writeEscaped(result, line)
+		}
+
+		switch span.Style {
+		case Chat.BoldStyle:
+			result.WriteString("</strong>")
+		case Chat.EmphasisStyle:
+			result.WriteString("</em>")
+		}
+
+		if span.Link != "" {
+			result.WriteString("</a>")
+		}
+	}
+}
+
+func writeEscaped(result *strings.Builder, text string) {
+	xml.EscapeText(result, []byte(text))
+}
+
+// Creates the plain text body for a message. Multi-line messages start on a new line so they aren't
+// pushed out of alignment by the sender's name.
+func plainBody(msg Chat.Message) string {
+	text := msg.PlainText()
+	if strings.Contains(text, "\n") {
+		return "\n" + text
+	}
+
+	return text
+}
+
+// Creates a raw message stanza with both a plain text and an XHTML-IM body
+func createRichStanza(remote string, msgType string, plain string, xhtml string) string {
+	var stanza strings.Builder
+
+	stanza.WriteString("<message to='")
+	writeEscaped(&stanza, remote)
+	stanza.WriteString("' type='")
+	writeEscaped(&stanza, msgType)
+	stanza.WriteString("' xml:lang='en'><body>")
+	writeEscaped(&stanza, plain)
+	stanza.WriteString("</body><html xmlns='" + nsXHTMLIM + "'><body xmlns='" + nsXHTML + "'>")
+	stanza.WriteString(xhtml)
+	stanza.WriteString("</body></html></message>")
+
+	return stanza.String()
+}
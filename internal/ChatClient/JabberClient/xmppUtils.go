@@ -1,12 +1,16 @@
 package jabber
 
 import (
+	Chat "IncursionBot/internal/ChatClient"
+	"encoding/xml"
 	"regexp"
+	"strings"
 )
 
 const (
 	conferenceChat string = "groupchat"
 	privateMessage string = "chat"
+	errorMessage   string = "error"
 )
 
 // Parse the JID to extract the MUC it came from
@@ -16,3 +20,38 @@ func parseMuc(jid string, server string) string {
 
 	return muc
 }
+
+// Strip the resource off a JID
+func bareJID(jid string) string {
+	bare, _, _ := strings.Cut(jid, "/")
+	return bare
+}
+
+// The JID ad-hoc commands are keyed by. Someone writing from a MUC on the server is only known by their occupant JID,
+// the bare JID would be the room itself.
+func commandSender(jid string, server string) string {
+	bare := bareJID(jid)
+	_, domain, _ := strings.Cut(bare, "@")
+	if domain == server {
+		return jid
+	}
+	return bare
+}
+
+// Create a raw presence stanza, go-xmpp can't send a status message on its own
+func createPresence(presence Chat.Presence) string {
+	var stanza strings.Builder
+
+	stanza.WriteString("<presence>")
+	if presence.Away {
+		stanza.WriteString("<show>away</show>")
+	}
+	if presence.Status != "" {
+		stanza.WriteString("<status>")
+		xml.EscapeText(&stanza, []byte(presence.Status))
+		stanza.WriteString("</status>")
+	}
+	stanza.WriteString("</presence>")
+
+	return stanza.String()
+}
@@ -1,6 +1,7 @@
 package jabber
 
 import (
+	Chat "IncursionBot/internal/ChatClient"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,3 +16,50 @@ func TestParseMUC(t *testing.T) {
 
 	assert.Equal(t, testMUC, muc)
 }
+
+func TestCommandSender(t *testing.T) {
+	assert := assert.New(t)
+	testServer := "conference.test.com"
+
+	assert.Equal("user@test.com", commandSender("user@test.com/phone", testServer))
+	assert.Equal("user@test.com", commandSender("user@test.com", testServer))
+	assert.Equal("testRoom@"+testServer+"/someUser", commandSender("testRoom@"+testServer+"/someUser", testServer))
+}
+
+func TestCreatePresence(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("<presence></presence>", createPresence(Chat.Presence{}))
+	assert.Equal("<presence><status>2 null, 1 low</status></presence>", createPresence(Chat.Presence{Status: "2 null, 1 low"}))
+	assert.Equal("<presence><show>away</show><status>ESI &lt;down&gt; &amp; stale</status></presence>",
+		createPresence(Chat.Presence{Away: true, Status: "ESI <down> & stale"}))
+}
+
+func TestRenderXHTML(t *testing.T) {
+	assert := assert.New(t)
+
+	var msg Chat.Message
+	msg = msg.Heading(Chat.Plain("Layout <1>")).
+		Paragraph(Chat.Emphasis("line\nbreak"), Chat.Link("dotlan", "https://evemaps.dotlan.net/system/1DQ1-A?a=1&b=2")).
+		List([]Chat.Span{Chat.Bold("!help")}).
+		Table([]string{"Site", "System"}, []Chat.Span{Chat.Plain("HQ"), Chat.Plain("T5ZI-S")})
+
+	expected := "<p><strong>Layout &lt;1&gt;</strong></p>" +
+		"<p><em>line<br/>break</em><a href='https://evemaps.dotlan.net/system/1DQ1-A?a=1&amp;b=2'>dotlan</a></p>" +
+		"<ul><li><strong>!help</strong></li></ul>" +
+		"<p><strong>Site | System</strong><br/>HQ | T5ZI-S</p>"
+
+	assert.Equal(expected, renderXHTML(msg))
+}
+
+func TestPlainBody(t *testing.T) {
+	assert.Equal(t, "one line", plainBody(Chat.Text("one line")))
+	assert.Equal(t, "\ntwo\nlines", plainBody(Chat.Text("two\nlines")))
+}
+
+func TestCreateRichStanza(t *testing.T) {
+	expected := "<message to='room@test.com' type='groupchat' xml:lang='en'><body>a &amp; b</body>" +
+		"<html xmlns='http://jabber.org/protocol/xhtml-im'><body xmlns='http://www.w3.org/1999/xhtml'><p>a &amp; b</p></body></html></message>"
+
+	assert.Equal(t, expected, createRichStanza("room@test.com", "groupchat", "a & b", "<p>a &amp; b</p>"))
+}
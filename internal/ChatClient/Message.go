@@ -0,0 +1,167 @@
+package Chat
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type SpanStyle int
+
+const (
+	PlainStyle SpanStyle = iota
+	BoldStyle
+	EmphasisStyle
+)
+
+// Run of text with a single style, optionally linking somewhere
+type Span struct {
+	Text  string
+	Style SpanStyle
+	Link  string // URL the text links to, if any
+}
+
+func Plain(text string) Span            { return Span{Text: text} }
+func Bold(text string) Span             { return Span{Text: text, Style: BoldStyle} }
+func Emphasis(text string) Span         { return Span{Text: text, Style: EmphasisStyle} }
+func Link(text string, url string) Span { return Span{Text: text, Link: url} }
+
+type BlockKind int
+
+const (
+	HeadingBlock BlockKind = iota
+	ParagraphBlock
+	ListBlock
+	TableBlock
+)
+
+// Single piece of a message's layout
+type Block struct {
+	Kind    BlockKind
+	Spans   []Span   // Content of headings and paragraphs
+	Items   [][]Span // Entries of lists
+	Columns []string // Table header
+	Rows    [][]Span // Table rows, one span per cell
+}
+
+// Formatted message, rendered by each chat backend in its native format
+type Message struct {
+	Blocks []Block
+}
+
+// Creates a message containing a single paragraph of plain text
+func Text(text string) Message {
+	var msg Message
+	return msg.Paragraph(Plain(text))
+}
+
+func (msg Message) Heading(spans ...Span) Message {
+	msg.Blocks = append(msg.Blocks, Block{Kind: HeadingBlock, Spans: spans})
+	return msg
+}
+
+func (msg Message) Paragraph(spans ...Span) Message {
+	msg.Blocks = append(msg.Blocks, Block{Kind: ParagraphBlock, Spans: spans})
+	return msg
+}
+
+func (msg Message) List(items ...[]Span) Message {
+	msg.Blocks = append(msg.Blocks, Block{Kind: ListBlock, Items: items})
+	return msg
+}
+
+func (msg Message) Table(columns []string, rows ...[]Span) Message {
+	msg.Blocks = append(msg.Blocks, Block{Kind: TableBlock, Columns: columns, Rows: rows})
+	return msg
+}
+
+// Checks if the message is nothing but unstyled text, so backends can skip rich formatting
+func (msg Message) IsPlain() bool {
+	for _, block := range msg.Blocks {
+		if block.Kind != ParagraphBlock {
+			return false
+		}
+
+		for _, span := range block.Spans {
+			if span.Style != PlainStyle || span.Link != "" {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Renders the message as plain text, for backends without rich formatting and as a fallback
+func (msg Message) PlainText() string {
+	var lines []string
+
+	for _, block := range msg.Blocks {
+		switch block.Kind {
+		case HeadingBlock, ParagraphBlock:
+			lines = append(lines, SpansText(block.Spans))
+		case ListBlock:
+			for _, item := range block.Items {
+				lines = append(lines, "- "+SpansText(item))
+			}
+		case TableBlock:
+			lines = append(lines, plainTable(block)...)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Joins spans into plain text, putting link URLs after their text
+func SpansText(spans []Span) string {
+	var result strings.Builder
+
+	for _, span := range spans {
+		result.WriteString(span.Text)
+		if span.Link != "" && span.Link != span.Text {
+			fmt.Fprintf(&result, " (%s)", span.Link)
+		}
+	}
+
+	return result.String()
+}
+
+// Lays the table out in padded columns
+func plainTable(table Block) []string {
+	widths := make([]int, len(table.Columns))
+	cells := make([][]string, len(table.Rows))
+
+	for i, column := range table.Columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+
+	for i, row := range table.Rows {
+		for j, cell := range row {
+			text := SpansText([]Span{cell})
+			cells[i] = append(cells[i], text)
+			if j < len(widths) && utf8.RuneCountInString(text) > widths[j] {
+				widths[j] = utf8.RuneCountInString(text)
+			}
+		}
+	}
+
+	lines := []string{padRow(table.Columns, widths)}
+	for _, row := range cells {
+		lines = append(lines, padRow(row, widths))
+	}
+
+	return lines
+}
+
+func padRow(cells []string, widths []int) string {
+	var padded []string
+
+	for i, cell := range cells {
+		if i < len(widths) && i < len(cells)-1 {
+			cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		}
+		padded = append(padded, cell)
+	}
+
+	return strings.Join(padded, "  ")
+}
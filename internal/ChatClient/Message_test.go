@@ -0,0 +1,37 @@
+package Chat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPlain(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(Text("hello").IsPlain())
+
+	var msg Message
+	assert.False(msg.Paragraph(Plain("hello "), Bold("world")).IsPlain())
+	assert.False(msg.Heading(Plain("hello")).IsPlain())
+	assert.False(msg.Paragraph(Link("dotlan", "https://evemaps.dotlan.net")).IsPlain())
+}
+
+func TestPlainText(t *testing.T) {
+	assert := assert.New(t)
+
+	var msg Message
+	msg = msg.Heading(Plain("Layout")).
+		Paragraph(Plain("See "), Link("dotlan", "https://evemaps.dotlan.net"), Plain(" or "), Link("https://a.b", "https://a.b")).
+		List([]Span{Bold("!help"), Plain(" - help")}).
+		Table([]string{"Site", "System"}, []Span{Plain("Staging"), Plain("1DQ1-A")}, []Span{Plain("HQ"), Plain("T5ZI-S")})
+
+	expected := "Layout\n" +
+		"See dotlan (https://evemaps.dotlan.net) or https://a.b\n" +
+		"- !help - help\n" +
+		"Site     System\n" +
+		"Staging  1DQ1-A\n" +
+		"HQ       T5ZI-S"
+
+	assert.Equal(expected, msg.PlainText())
+}
@@ -1,6 +1,7 @@
 package ESI
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
@@ -10,18 +11,15 @@ type AllianceDetailResponse struct {
 	Ticker string
 }
 
-var allianceCache = make(CacheMap)
-
-func (c *ESIClient) GetAllianceData(allianceID int) (AllianceDetailResponse, error) {
+func (c *ESIClient) GetAllianceData(ctx context.Context, allianceID int) (AllianceDetailResponse, error) {
 	var resultData AllianceDetailResponse
-	url := fmt.Sprintf("%s/alliances/%d", c.baseURL, allianceID)
+	url := fmt.Sprintf("%s/alliances/%d/", c.baseURL, allianceID)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return resultData, err
 	}
 
-	cacheData := allianceCache[allianceID]
-	err = c.cachedCall(req, &cacheData, &resultData)
-	return resultData, err
+	entry, err := cachedCall[AllianceDetailResponse](c, req, true)
+	return entry.Data, err
 }
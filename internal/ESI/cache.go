@@ -1,18 +1,160 @@
 package ESI
 
 import (
-	"reflect"
+	"container/list"
+	"sync"
 	"time"
 )
 
-type CacheEntry struct {
-	Data           reflect.Value
+const defaultCacheSize int = 20000 // Enough for every system and stargate near the incursions, plus names
+
+// A cached response along with what's needed to revalidate it
+type CacheEntry[T any] struct {
+	Data           T
 	ExpirationTime time.Time
 	Etag           string
+	Modified       time.Time // When ESI last changed the data
 }
 
-func (entry *CacheEntry) Expired() bool {
+func (entry *CacheEntry[T]) Expired() bool {
 	return time.Now().After(entry.ExpirationTime)
 }
 
-type CacheMap map[int]CacheEntry
+// Hit and miss counts for a cache
+type CacheStats struct {
+	Entries   int    `json:"entries"`
+	Hits      uint64 `json:"hits"`      // Lookups that found an entry, expired or not
+	Misses    uint64 `json:"misses"`    // Lookups that found nothing
+	Evictions uint64 `json:"evictions"` // Entries dropped to stay within the size limit
+}
+
+// Concurrency-safe cache of ESI responses keyed by request, evicting the least recently used entries once full
+type Cache struct {
+	MaxEntries int        // Defaults to 20000 if unset
+	Disk       *DiskCache // Keeps persistent entries between restarts, memory only if nil
+
+	mut     sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Most recently used at the front
+	stats   CacheStats
+}
+
+type cacheItem struct {
+	key   string
+	entry any // Always a CacheEntry of the type the key was stored with
+}
+
+func NewCache(maxEntries int) *Cache {
+	return &Cache{MaxEntries: maxEntries}
+}
+
+// Gets the stored entry for the key, if there is one of the given type
+func cacheGet[T any](cache *Cache, key string) (CacheEntry[T], bool) {
+	cache.mut.Lock()
+	defer cache.mut.Unlock()
+
+	element, pres := cache.entries[key]
+	if !pres {
+		cache.stats.Misses++
+		return CacheEntry[T]{}, false
+	}
+
+	entry, ok := element.Value.(*cacheItem).entry.(CacheEntry[T])
+	if !ok {
+		cache.stats.Misses++
+		return CacheEntry[T]{}, false
+	}
+
+	cache.stats.Hits++
+	cache.order.MoveToFront(element)
+	return entry, true
+}
+
+// Looks at the stored entry for the key without counting it as a use
+func cachePeek[T any](cache *Cache, key string) (CacheEntry[T], bool) {
+	if cache == nil {
+		return CacheEntry[T]{}, false
+	}
+
+	cache.mut.Lock()
+	defer cache.mut.Unlock()
+
+	element, pres := cache.entries[key]
+	if !pres {
+		return CacheEntry[T]{}, false
+	}
+
+	entry, ok := element.Value.(*cacheItem).entry.(CacheEntry[T])
+	return entry, ok
+}
+
+// Stores the entry for the key, evicting the least recently used entries if the cache is full
+func cacheSet[T any](cache *Cache, key string, entry CacheEntry[T]) {
+	cache.mut.Lock()
+	defer cache.mut.Unlock()
+
+	if cache.entries == nil {
+		cache.entries = make(map[string]*list.Element)
+		cache.order = list.New()
+	}
+
+	if element, pres := cache.entries[key]; pres {
+		element.Value.(*cacheItem).entry = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(&cacheItem{key: key, entry: entry})
+
+	maxEntries := cache.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheSize
+	}
+
+	for cache.order.Len() > maxEntries {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheItem).key)
+		cache.stats.Evictions++
+	}
+}
+
+// Gets the entry for the key from memory, falling back to disk for persistent entries. A nil cache never has entries.
+func cacheLoad[T any](cache *Cache, key string, persist bool) (CacheEntry[T], bool) {
+	if cache == nil {
+		return CacheEntry[T]{}, false
+	}
+
+	entry, pres := cacheGet[T](cache, key)
+	if pres || !persist || cache.Disk == nil {
+		return entry, pres
+	}
+
+	entry, pres = diskGet[T](cache.Disk, key)
+	if pres {
+		cacheSet(cache, key, entry)
+	}
+	return entry, pres
+}
+
+// Stores the entry in memory, and on disk if it is persistent
+func cacheStore[T any](cache *Cache, key string, entry CacheEntry[T], persist bool) {
+	if cache == nil {
+		return
+	}
+
+	cacheSet(cache, key, entry)
+	if persist && cache.Disk != nil {
+		diskSet(cache.Disk, key, entry)
+	}
+}
+
+// Gets the current hit and miss counts
+func (cache *Cache) Stats() CacheStats {
+	cache.mut.Lock()
+	defer cache.mut.Unlock()
+
+	stats := cache.stats
+	stats.Entries = len(cache.entries)
+	return stats
+}
@@ -0,0 +1,113 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewCache(2)
+
+	_, pres := cacheGet[int](cache, "a")
+	assert.False(pres)
+
+	cacheSet(cache, "a", CacheEntry[int]{Data: 1, ExpirationTime: time.Now().Add(time.Minute)})
+	entry, pres := cacheGet[int](cache, "a")
+	assert.True(pres)
+	assert.Equal(1, entry.Data)
+	assert.False(entry.Expired())
+
+	// Entries are typed
+	_, pres = cacheGet[string](cache, "a")
+	assert.False(pres)
+
+	t.Run("Eviction", func(t *testing.T) {
+		cacheSet(cache, "b", CacheEntry[int]{Data: 2})
+		cacheGet[int](cache, "a") // b is now least recently used
+		cacheSet(cache, "c", CacheEntry[int]{Data: 3})
+
+		_, pres := cacheGet[int](cache, "b")
+		assert.False(pres)
+		_, pres = cacheGet[int](cache, "a")
+		assert.True(pres)
+		_, pres = cacheGet[int](cache, "c")
+		assert.True(pres)
+
+		stats := cache.Stats()
+		assert.Equal(2, stats.Entries)
+		assert.Equal(uint64(1), stats.Evictions)
+		assert.Equal(uint64(4), stats.Hits)
+		assert.Equal(uint64(3), stats.Misses)
+	})
+
+	t.Run("Concurrent use", func(t *testing.T) {
+		cache := NewCache(50)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					key := fmt.Sprint(j)
+					cacheSet(cache, key, CacheEntry[int]{Data: j})
+					if entry, pres := cacheGet[int](cache, key); pres {
+						assert.Equal(j, entry.Data)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(50, cache.Stats().Entries)
+	})
+}
+
+func TestDiskCache(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	disk, err := NewDiskCache(filepath.Join(t.TempDir(), "esi"))
+	assert.NoError(err)
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	diskSet(disk, "GET /systems/1", CacheEntry[SystemData]{Data: SystemData{ID: 1, Name: "Jita"}, ExpirationTime: expires, Etag: "tag"})
+	diskSet(disk, "name 1", CacheEntry[string]{Data: "Jita", ExpirationTime: expires})
+
+	// Entries with an ETag are revalidated before use
+	system, pres := diskGet[SystemData](disk, "GET /systems/1")
+	assert.True(pres)
+	assert.Equal("Jita", system.Data.Name)
+	assert.Equal("tag", system.Etag)
+	assert.True(system.Expired())
+
+	name, pres := diskGet[string](disk, "name 1")
+	assert.True(pres)
+	assert.Equal("Jita", name.Data)
+	assert.True(expires.Equal(name.ExpirationTime))
+
+	_, pres = diskGet[string](disk, "name 2")
+	assert.False(pres)
+
+	t.Run("Loaded into memory", func(t *testing.T) {
+		cache := NewCache(10)
+		cache.Disk = disk
+
+		_, pres := cacheLoad[string](cache, "name 1", false)
+		assert.False(pres)
+
+		_, pres = cacheLoad[string](cache, "name 1", true)
+		assert.True(pres)
+		_, pres = cacheGet[string](cache, "name 1")
+		assert.True(pres)
+
+		cacheStore(cache, "name 3", CacheEntry[string]{Data: "Amarr"}, false)
+		_, pres = diskGet[string](disk, "name 3")
+		assert.False(pres)
+	})
+}
@@ -0,0 +1,101 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Keeps cached responses as files so static data survives restarts. Each entry is a JSON file named after a hash of its key.
+type DiskCache struct {
+	Dir string
+}
+
+type diskEntry struct {
+	Key      string          `json:"key"`
+	Etag     string          `json:"etag,omitempty"`
+	Expires  time.Time       `json:"expires"`
+	Modified time.Time       `json:"modified"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// Creates the cache directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{Dir: dir}, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, hex.EncodeToString(hash[:16])+".json")
+}
+
+// Reads the stored entry for the key. Entries with an ETag come back expired so they are revalidated before use.
+func diskGet[T any](d *DiskCache, key string) (CacheEntry[T], bool) {
+	var stored diskEntry
+	var entry CacheEntry[T]
+
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return entry, false
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &stored)
+	}
+	if err == nil && stored.Key != key {
+		return entry, false
+	}
+	if err == nil {
+		err = json.Unmarshal(stored.Body, &entry.Data)
+	}
+	if err != nil {
+		logging.Warningf("Ignoring unreadable ESI cache entry for %s: %v", key, err)
+		return entry, false
+	}
+
+	entry.Etag = stored.Etag
+	entry.Modified = stored.Modified
+	if entry.Etag == "" {
+		entry.ExpirationTime = stored.Expires
+	}
+	return entry, true
+}
+
+// Writes the entry for the key, replacing the file atomically so a crash never leaves half an entry behind
+func diskSet[T any](d *DiskCache, key string, entry CacheEntry[T]) {
+	body, err := json.Marshal(entry.Data)
+	var data []byte
+	if err == nil {
+		data, err = json.Marshal(diskEntry{Key: key, Etag: entry.Etag, Expires: entry.ExpirationTime, Modified: entry.Modified, Body: body})
+	}
+
+	var temp *os.File
+	if err == nil {
+		temp, err = os.CreateTemp(d.Dir, "*.tmp")
+	}
+	if err == nil {
+		_, err = temp.Write(data)
+		closeErr := temp.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(temp.Name(), d.path(key))
+		}
+		if err != nil {
+			os.Remove(temp.Name())
+		}
+	}
+	if err != nil {
+		logging.Errorf("Failed to save ESI cache entry for %s: %v", key, err)
+	}
+}
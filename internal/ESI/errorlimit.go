@@ -0,0 +1,182 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"IncursionBot/internal/Utils"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const maxConcurrentRequests int = 10 // Requests sent to ESI at once, the rest wait their turn
+const errorLimitSlow int = 50        // Below this many errors left, requests are spread out over the rest of the window
+const errorLimitPause int = 10       // Below this many errors left, requests wait for the window to reset
+
+const statusErrorLimited int = 420 // Sent by ESI once the error limit is used up
+
+// ESI's error limit as last reported, along with how many requests are waiting on it
+type ErrorBudget struct {
+	Remaining int       `json:"remaining"` // Errors left in the current window, -1 if ESI hasn't said
+	Reset     time.Time `json:"reset"`     // When the window resets
+	Paused    bool      `json:"paused"`    // Requests are held until the window resets
+	InFlight  int       `json:"inFlight"`
+	Queued    int       `json:"queued"`
+	Errors    uint64    `json:"errors"` // Error responses since the bot started
+}
+
+// Keeps requests within ESI's error limit, which gets the client banned if exceeded. Shared by copies of a client.
+type errorLimiter struct {
+	mut       sync.Mutex
+	remaining int // -1 if unknown
+	reset     time.Time
+	next      time.Time // Earliest the next request can go out while slowed down
+	paused    bool
+	queued    int
+	inFlight  int
+	errors    uint64
+	slots     chan struct{}
+
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+func newErrorLimiter() *errorLimiter {
+	return &errorLimiter{
+		remaining: -1,
+		slots:     make(chan struct{}, maxConcurrentRequests),
+		now:       time.Now,
+		sleep:     Utils.SleepContext,
+	}
+}
+
+// Blocks until the request can be sent or the context is cancelled. Every successful call must be followed by a call to done.
+func (l *errorLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mut.Lock()
+	l.queued++
+	l.mut.Unlock()
+
+	err := l.takeSlot(ctx)
+
+	l.mut.Lock()
+	l.queued--
+	if err == nil {
+		l.inFlight++
+	}
+	l.mut.Unlock()
+
+	return err
+}
+
+func (l *errorLimiter) takeSlot(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for {
+		l.mut.Lock()
+		delay := l.delay()
+		l.mut.Unlock()
+
+		if delay <= 0 {
+			return nil
+		}
+
+		err := l.sleep(ctx, delay)
+		if err != nil {
+			<-l.slots
+			return err
+		}
+	}
+}
+
+// How long to hold the next request for. Must be called with the lock held.
+func (l *errorLimiter) delay() time.Duration {
+	now := l.now()
+	if l.remaining < 0 || !now.Before(l.reset) {
+		// Nothing known about the current window
+		if l.paused {
+			logging.Infoln("ESI error limit reset, resuming requests")
+		}
+		l.paused = false
+		return 0
+	}
+
+	if l.remaining <= errorLimitPause {
+		if !l.paused {
+			logging.Warningf("Only %d ESI errors left before being banned, pausing requests until %s", l.remaining, l.reset.UTC().Format(time.TimeOnly))
+		}
+		l.paused = true
+		return l.reset.Sub(now)
+	}
+
+	if l.remaining <= errorLimitSlow {
+		if now.Before(l.next) {
+			return l.next.Sub(now)
+		}
+		l.next = now.Add(l.reset.Sub(now) / time.Duration(l.remaining))
+	}
+
+	return 0
+}
+
+// Records the error limit from a response and frees the request's slot
+func (l *errorLimiter) done(resp *http.Response) {
+	if l == nil {
+		return
+	}
+	defer func() { <-l.slots }()
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.inFlight--
+	if resp == nil {
+		return
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		l.errors++
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-ESI-Error-Limit-Remain"))
+	if err == nil {
+		l.remaining = remaining
+	}
+	reset, err := strconv.Atoi(resp.Header.Get("X-ESI-Error-Limit-Reset"))
+	if err == nil {
+		l.reset = l.now().Add(time.Duration(reset) * time.Second)
+	}
+
+	if resp.StatusCode == statusErrorLimited {
+		l.remaining = 0
+		if !l.reset.After(l.now()) {
+			l.reset = l.now().Add(time.Minute)
+		}
+	}
+}
+
+func (l *errorLimiter) budget() ErrorBudget {
+	if l == nil {
+		return ErrorBudget{Remaining: -1}
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	return ErrorBudget{
+		Remaining: l.remaining,
+		Reset:     l.reset,
+		Paused:    l.paused,
+		InFlight:  l.inFlight,
+		Queued:    l.queued,
+		Errors:    l.errors,
+	}
+}
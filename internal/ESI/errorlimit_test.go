@@ -0,0 +1,117 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func limitResponse(status int, remaining string, reset string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+	resp.Header.Set("X-ESI-Error-Limit-Remain", remaining)
+	resp.Header.Set("X-ESI-Error-Limit-Reset", reset)
+	return resp
+}
+
+func TestErrorLimiter(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	ctx := context.Background()
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	limiter := newErrorLimiter()
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	// Plenty of budget
+	limiter.wait(ctx)
+	assert.Equal(1, limiter.budget().InFlight)
+	limiter.done(limitResponse(http.StatusOK, "100", "60"))
+	limiter.wait(ctx)
+	limiter.done(limitResponse(http.StatusNotFound, "99", "60"))
+	assert.Empty(slept)
+
+	budget := limiter.budget()
+	assert.Equal(99, budget.Remaining)
+	assert.Equal(now.Add(time.Minute), budget.Reset)
+	assert.Equal(uint64(1), budget.Errors)
+	assert.Equal(0, budget.InFlight)
+
+	t.Run("Slowed down", func(t *testing.T) {
+		slept = nil
+		limiter.wait(ctx)
+		limiter.done(limitResponse(http.StatusOK, "30", "60"))
+
+		limiter.wait(ctx) // Spaces requests over the rest of the window
+		limiter.done(limitResponse(http.StatusOK, "30", "60"))
+		limiter.wait(ctx)
+		limiter.done(nil)
+		assert.Equal([]time.Duration{time.Second * 2}, slept)
+	})
+
+	t.Run("Paused", func(t *testing.T) {
+		limiter.wait(ctx)
+		limiter.done(limitResponse(statusErrorLimited, "", ""))
+		assert.Equal(0, limiter.budget().Remaining)
+		reset := limiter.budget().Reset
+		slept = nil
+
+		limiter.wait(ctx) // Held until the window resets
+		assert.Len(slept, 1)
+		assert.Equal(reset, now)
+		assert.False(limiter.budget().Paused)
+		limiter.done(limitResponse(http.StatusOK, "100", "60"))
+	})
+}
+
+func TestErrorLimitHeaders(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "87")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "42")
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	assert.Equal(-1, client.ErrorBudget().Remaining)
+
+	_, err := client.GetRouteLength(context.Background(), 1, 2)
+	assert.Error(err)
+
+	budget := client.ErrorBudget()
+	assert.Equal(87, budget.Remaining)
+	assert.Equal(uint64(1), budget.Errors)
+	assert.WithinDuration(time.Now().Add(time.Second*42), budget.Reset, time.Second*5)
+}
+
+func TestErrorLimiterCancel(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	limiter := newErrorLimiter()
+	assert.NoError(limiter.wait(context.Background()))
+	limiter.done(limitResponse(statusErrorLimited, "0", "60"))
+
+	// Waiting out the pause gives up with the context
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(limiter.wait(ctx), context.DeadlineExceeded)
+
+	budget := limiter.budget()
+	assert.Equal(0, budget.Queued)
+	assert.Equal(0, budget.InFlight)
+	assert.Empty(limiter.slots)
+}
@@ -2,32 +2,93 @@ package ESI
 
 import (
 	logging "IncursionBot/internal/Logging"
+	universe "IncursionBot/internal/Universe"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"reflect"
+	"sync/atomic"
 	"time"
 )
 
 const esiURL string = "https://esi.evetech.net/"
 
+// Copies of a client share its cache
 type ESIClient struct {
-	baseURL string
+	baseURL    string
+	userAgent  string
+	httpClient *http.Client
+	cache      *Cache
+	limiter    *errorLimiter
+	retries    *retryPolicy
+	breakers   *breakerSet
+	flights    *flightGroup
+	health     *healthMonitor
+	graph      *atomic.Pointer[universe.Universe] // Answers universe lookups without ESI once set
 }
 
-func NewClient() ESIClient {
-	return ESIClient{baseURL: esiURL + "latest"}
+func NewClient(options ...ClientOption) ESIClient {
+	return NewClientWithVersion("latest", options...)
 }
 
-func NewClientWithVersion(version string) ESIClient {
-	return ESIClient{baseURL: esiURL + version}
+func NewClientWithVersion(version string, options ...ClientOption) ESIClient {
+	client := ESIClient{
+		baseURL:    esiURL + version,
+		userAgent:  defaultUserAgent,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		cache:      NewCache(defaultCacheSize),
+		limiter:    newErrorLimiter(),
+		retries:    &retryPolicy{maxAttempts: defaultMaxAttempts, baseDelay: defaultRetryDelay},
+		breakers:   newBreakerSet(defaultBreakerThreshold, defaultBreakerCooldown),
+		flights:    newFlightGroup(),
+		health:     newHealthMonitor(),
+		graph:      new(atomic.Pointer[universe.Universe]),
+	}
+
+	for _, option := range options {
+		option(&client)
+	}
+
+	return client
 }
 
 func init() {
 }
 
+// Sends a request to ESI with the client's HTTP client and user agent, once the error limit allows it
+func (c *ESIClient) do(req *http.Request) (*http.Response, error) {
+	userAgent := c.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	err := c.limiter.wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	c.limiter.done(resp)
+	if req.Context().Err() == nil {
+		c.health.record(c.endpointName(req), time.Since(start), resp, err)
+	}
+	return resp, err
+}
+
+// Gets ESI's error limit as of the last response
+func (c *ESIClient) ErrorBudget() ErrorBudget {
+	return c.limiter.budget()
+}
+
 // Parse JSON results from HTTP response into a given struct
 func (c *ESIClient) parseResults(resp *http.Response, resultStruct interface{}) error {
 	if resp == nil {
@@ -47,67 +108,120 @@ func parseExpirationTime(resp *http.Response) (time.Time, error) {
 	return time.Parse(time.RFC1123, resp.Header.Get("Expires"))
 }
 
-func (c *ESIClient) cachedCall(req *http.Request, cache *CacheEntry, resultStruct interface{}) error {
-	if req == nil || cache == nil {
-		return fmt.Errorf("one of the inputs was null")
+// When the response should be refreshed. Responses without a usable Expires header are revalidated on the next call.
+func expirationTime(resp *http.Response) time.Time {
+	expires, err := parseExpirationTime(resp)
+	if err != nil {
+		logging.Warningf("Invalid Expires header from %s: %v", resp.Request.URL.Path, err)
+		return time.Now()
 	}
+	return expires
+}
 
-	result := reflect.ValueOf(resultStruct)
+// When ESI last changed the response's data, or now if it doesn't say
+func lastModified(resp *http.Response) time.Time {
+	modified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return time.Now()
+	}
+	return modified
+}
 
-	if !cache.Expired() {
-		result.Elem().Set(cache.Data)
-		return nil
+// Cached responses are keyed by the request that fetched them
+func requestKey(method string, url string) string {
+	return method + " " + url
+}
+
+// Makes the request, answering from the cache while the entry is fresh and revalidating it with its ETag once it expires.
+// Persistent responses are also kept on disk, if the client has a disk cache.
+func cachedCall[T any](c *ESIClient, req *http.Request, persist bool) (CacheEntry[T], error) {
+	if req == nil {
+		return CacheEntry[T]{}, fmt.Errorf("request was nil")
+	}
+
+	key := requestKey(req.Method, req.URL.String())
+	cache, cached := cacheLoad[T](c.cache, key, persist)
+
+	if cached && !cache.Expired() {
+		return cache, nil
 	}
 
-	req.Header.Add("If-None-Match", cache.Etag)
+	// Anyone else asking for the same thing right now shares the one request
+	result, err := c.flights.do(req.Context(), key, func(ctx context.Context) (any, error) {
+		return fetchEntry(c, req.WithContext(ctx), key, cache, cached, persist)
+	})
 
-	resp, err := http.DefaultClient.Do(req)
+	entry, ok := result.(CacheEntry[T])
+	if !ok {
+		entry = cache
+	}
+	return entry, err
+}
+
+// Requests a new or revalidated entry from ESI, falling back to the stale entry if ESI is having problems
+func fetchEntry[T any](c *ESIClient, req *http.Request, key string, cache CacheEntry[T], cached bool, persist bool) (CacheEntry[T], error) {
+	if cached && cache.Etag != "" {
+		req.Header.Set("If-None-Match", cache.Etag)
+	}
+
+	resp, err := c.send(req)
+	if err != nil && cached && req.Context().Err() == nil {
+		logging.Warningf("ESI is unavailable, returning cached data instead: %v", err)
+		return cache, nil
+	}
 	if err != nil {
-		return err
+		return cache, err
 	}
+	defer resp.Body.Close()
 
 	switch resp.StatusCode {
 	case http.StatusOK: // Expected case
-		err = c.parseResults(resp, resultStruct)
+		var result T
+		err = c.parseResults(resp, &result)
 		if err != nil {
-			return err
+			return cache, err
 		}
-		cache.Data = result.Elem()
-		cache.ExpirationTime, err = parseExpirationTime(resp)
-		cache.Etag = resp.Header.Get("ETag")
-		return err
+		cache = CacheEntry[T]{Data: result, ExpirationTime: expirationTime(resp), Etag: resp.Header.Get("ETag"), Modified: lastModified(resp)}
 	case http.StatusNotModified:
-		if !cache.Data.IsValid() {
-			return fmt.Errorf("cache was empty")
+		if !cached {
+			return cache, fmt.Errorf("cache was empty")
 		}
-
-		result.Elem().Set(cache.Data)
-		cache.ExpirationTime, err = parseExpirationTime(resp)
-		return err
+		cache.ExpirationTime = expirationTime(resp)
 	case http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusGatewayTimeout, http.StatusBadGateway:
 		log.Println("ESI is having problems, returning cached data instead")
 		log.Printf("Got status code: %d", resp.StatusCode)
-		if !cache.Data.IsValid() {
-			return fmt.Errorf("cache was empty")
+		if !cached {
+			return cache, fmt.Errorf("cache was empty")
 		}
-
-		result.Elem().Set(cache.Data)
-		return nil
+		return cache, nil
 	default:
 		data, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("status code %d received from server: %s", resp.StatusCode, string(data))
+		return cache, fmt.Errorf("status code %d received from server: %s", resp.StatusCode, string(data))
 	}
+
+	cacheStore(c.cache, key, cache, persist)
+	return cache, nil
 }
 
-func (c *ESIClient) CheckESI() bool {
-	// TODO: Mess with this so it uses swagger to verify the integrety of each endpoint
-	url := fmt.Sprintf("%s/swagger.json", c.baseURL)
-	resp, err := http.Get(url)
+// Keeps static universe data in the directory so it doesn't need downloading again after a restart
+func (c *ESIClient) UseDiskCache(dir string) error {
+	if c.cache == nil {
+		return fmt.Errorf("client has no cache")
+	}
 
+	disk, err := NewDiskCache(dir)
 	if err != nil {
-		logging.Errorln("Error occurred querying ESI:", err)
-		return false
+		return err
 	}
 
-	return resp.StatusCode == http.StatusOK
+	c.cache.Disk = disk
+	return nil
+}
+
+// Gets the hit and miss counts for the client's cache
+func (c *ESIClient) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.Stats()
 }
@@ -1,10 +1,10 @@
 package ESI
 
 import (
+	logging "IncursionBot/internal/Logging"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"reflect"
 	"testing"
 	"time"
 
@@ -16,13 +16,13 @@ var testReturn int = 3
 const testETag = "abcde"
 
 func return304(rw http.ResponseWriter, req *http.Request) {
-	rw.Header().Set("Expires", time.Now().Format(time.RFC1123))
+	rw.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(time.RFC1123))
 	rw.WriteHeader(http.StatusNotModified)
 }
 
 func successfulReturn(rw http.ResponseWriter, req *http.Request) {
 	result, _ := json.Marshal(testReturn)
-	rw.Header().Set("Expires", time.Now().Format(time.RFC1123))
+	rw.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(time.RFC1123))
 	rw.Header().Set("ETag", testETag)
 
 	rw.Write(result)
@@ -30,65 +30,118 @@ func successfulReturn(rw http.ResponseWriter, req *http.Request) {
 
 func TestCachedCall(t *testing.T) {
 	assert := assert.New(t)
-	esi := ESIClient{}
-	var result int
-	var cache CacheEntry
-	var cacheValue int = 5
-
-	server := httptest.NewServer(http.HandlerFunc(successfulReturn))
-
-	testReq, _ := http.NewRequest("GET", server.URL, nil)
-
-	err := esi.cachedCall(testReq, nil, &result)
-	assert.Error(err)
-
-	err = esi.cachedCall(nil, &cache, &result)
+	logging.InitLogger(false)
+	esi := ESIClient{cache: NewCache(10)}
+	var requests int
+	var etag string
+	handler := http.HandlerFunc(successfulReturn)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag = r.Header.Get("If-None-Match")
+		handler(w, r)
+	}))
+	defer server.Close()
+
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		return req
+	}
+	key := "GET " + server.URL
+	expire := func() {
+		entry, _ := cacheGet[int](esi.cache, key)
+		entry.ExpirationTime = time.Time{}
+		cacheSet(esi.cache, key, entry)
+	}
+
+	_, err := cachedCall[int](&esi, nil, false)
 	assert.Error(err)
 
 	// Normal path
-	err = esi.cachedCall(testReq, &cache, &result)
+	entry, err := cachedCall[int](&esi, newRequest(), false)
 	assert.NoError(err)
-	assert.Equal(testReturn, result)
-	assert.Equal(testReturn, int(cache.Data.Int()))
-	assert.Equal(cache.Etag, testETag)
-
-	cache.Data.Set(reflect.ValueOf(cacheValue))
-	cache.ExpirationTime = time.Time{}
-	assert.True(cache.Expired())
+	assert.Equal(testReturn, entry.Data)
+	assert.Equal(testETag, entry.Etag)
+	assert.Equal(1, requests)
+	assert.Empty(etag)
 
 	t.Run("Caching", func(t *testing.T) {
-		// Expired cache
-		err := esi.cachedCall(testReq, &cache, &result)
+		// Fresh entries are answered from the cache
+		entry, err := cachedCall[int](&esi, newRequest(), false)
 		assert.NoError(err)
-		assert.Equal(testReturn, result)
-		assert.Equal(testReturn, int(cache.Data.Int()))
-
-		// Non-expired cache
-		cache.Data.Set(reflect.ValueOf(cacheValue))
-		cache.ExpirationTime = time.Now().Add(time.Minute)
-		assert.False(cache.Expired())
+		assert.Equal(testReturn, entry.Data)
+		assert.Equal(1, requests)
 
-		err = esi.cachedCall(testReq, &cache, &result)
+		// Expired entries are revalidated
+		expire()
+		entry, err = cachedCall[int](&esi, newRequest(), false)
 		assert.NoError(err)
-		assert.Equal(cacheValue, result)
+		assert.Equal(testReturn, entry.Data)
+		assert.Equal(2, requests)
+		assert.Equal(testETag, etag)
 	})
 
 	t.Run("Error returns", func(t *testing.T) {
 		// NOT MODIFIED
-		server.Config.Handler = http.HandlerFunc(return304)
-		cache.ExpirationTime = time.Time{}
-		cache.Data.Set(reflect.ValueOf(cacheValue))
-		err = esi.cachedCall(testReq, &cache, &result)
+		handler = return304
+		expire()
+		entry, err := cachedCall[int](&esi, newRequest(), false)
 		assert.NoError(err)
-		assert.Equal(cacheValue, result)
-		assert.False(cache.ExpirationTime.IsZero())
+		assert.Equal(testReturn, entry.Data)
+		assert.False(entry.Expired())
 
-		// Zero Cache with Server error
-		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Server errors fall back to the stale entry
+		handler = func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
-		})
-		cache.Data = reflect.Value{}
-		err = esi.cachedCall(testReq, &cache, &result)
+		}
+		expire()
+		entry, err = cachedCall[int](&esi, newRequest(), false)
+		assert.NoError(err)
+		assert.Equal(testReturn, entry.Data)
+
+		// Empty cache with server error
+		_, err = cachedCall[int](&ESIClient{cache: NewCache(10)}, newRequest(), false)
+		assert.Error(err)
+
+		// Not modified without anything cached
+		handler = return304
+		_, err = cachedCall[int](&ESIClient{}, newRequest(), false)
 		assert.Error(err)
 	})
 }
+
+func TestPersistentCall(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	dir := t.TempDir()
+	var etag string
+	var status int = http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag = r.Header.Get("If-None-Match")
+		if status == http.StatusOK {
+			successfulReturn(w, r)
+		} else {
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+
+	esi := ESIClient{cache: NewCache(10)}
+	assert.NoError(esi.UseDiskCache(dir))
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	_, err := cachedCall[int](&esi, req, true)
+	assert.NoError(err)
+
+	// A restarted client revalidates the saved response, and falls back to it if ESI is down
+	for _, status = range []int{http.StatusNotModified, http.StatusServiceUnavailable} {
+		restarted := ESIClient{cache: NewCache(10)}
+		assert.NoError(restarted.UseDiskCache(dir))
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		entry, err := cachedCall[int](&restarted, req, true)
+		assert.NoError(err)
+		assert.Equal(testReturn, entry.Data)
+		assert.Equal(testETag, etag)
+	}
+}
@@ -0,0 +1,98 @@
+package ESI
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A request in progress, shared by everyone who asked for it
+type flight struct {
+	done     chan struct{}
+	result   any
+	err      error
+	waiters  int
+	deadline time.Time // Latest deadline of everyone who has waited for it, zero if any of them had none
+	cancel   context.CancelFunc
+}
+
+// Context the shared fetch runs with. It isn't cancelled by any one caller, but reports the latest of their deadlines so
+// the fetch doesn't start waits that nobody would be around to see the end of.
+type flightContext struct {
+	context.Context
+	group  *flightGroup
+	flight *flight
+}
+
+func (ctx flightContext) Deadline() (time.Time, bool) {
+	ctx.group.mut.Lock()
+	defer ctx.group.mut.Unlock()
+	return ctx.flight.deadline, !ctx.flight.deadline.IsZero()
+}
+
+// Coalesces concurrent requests for the same resource into a single request. Shared by copies of a client.
+type flightGroup struct {
+	mut     sync.Mutex
+	flights map[string]*flight
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight)}
+}
+
+// Runs fetch once for every concurrent caller with the same key and gives them all its result. Each caller stops waiting
+// when its own context ends, and the fetch is only cancelled once every caller has stopped waiting for it. The fetch sees
+// the latest of the callers' deadlines.
+func (g *flightGroup) do(ctx context.Context, key string, fetch func(context.Context) (any, error)) (any, error) {
+	if g == nil {
+		return fetch(ctx)
+	}
+
+	g.mut.Lock()
+	f, pres := g.flights[key]
+	if !pres {
+		cancelCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		f.deadline, _ = ctx.Deadline()
+		g.flights[key] = f
+
+		go func() {
+			f.result, f.err = fetch(flightContext{Context: cancelCtx, group: g, flight: f})
+
+			g.mut.Lock()
+			g.forget(key, f)
+			g.mut.Unlock()
+
+			cancel()
+			close(f.done)
+		}()
+	} else if deadline, ok := ctx.Deadline(); !ok {
+		f.deadline = time.Time{}
+	} else if !f.deadline.IsZero() && deadline.After(f.deadline) {
+		f.deadline = deadline
+	}
+	f.waiters++
+	g.mut.Unlock()
+
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		g.mut.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			g.forget(key, f) // Later callers start a new request rather than joining a cancelled one
+		}
+		g.mut.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
+
+// Removes the flight if it is still the one for the key. Must be called with the lock held.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
@@ -0,0 +1,139 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightGroup(t *testing.T) {
+	assert := assert.New(t)
+	group := newFlightGroup()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := group.do(context.Background(), "key", fetch)
+			assert.NoError(err)
+			assert.Equal(42, result)
+		}()
+	}
+
+	assert.Eventually(func() bool {
+		group.mut.Lock()
+		defer group.mut.Unlock()
+		return group.flights["key"] != nil && group.flights["key"].waiters == 5
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(int32(1), calls.Load())
+
+	t.Run("Cancelled once nobody is waiting", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		go cancel()
+
+		_, err := group.do(ctx, "slow", func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		})
+		assert.ErrorIs(err, context.Canceled)
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			assert.Fail("fetch wasn't cancelled")
+		}
+	})
+
+	t.Run("Latest deadline", func(t *testing.T) {
+		soon := time.Now().Add(time.Second)
+		later := soon.Add(time.Minute)
+		deadlines := make(chan time.Time)
+		release := make(chan struct{})
+		fetch := func(ctx context.Context) (any, error) {
+			<-release
+			deadline, _ := ctx.Deadline()
+			deadlines <- deadline
+			return nil, nil
+		}
+
+		join := func(ctx context.Context, waiters int) {
+			go group.do(ctx, "deadline", fetch)
+			assert.Eventually(func() bool {
+				group.mut.Lock()
+				defer group.mut.Unlock()
+				return group.flights["deadline"] != nil && group.flights["deadline"].waiters == waiters
+			}, time.Second, time.Millisecond)
+		}
+
+		ctx, cancel := context.WithDeadline(context.Background(), soon)
+		defer cancel()
+		join(ctx, 1)
+		ctx, cancel = context.WithDeadline(context.Background(), later)
+		defer cancel()
+		join(ctx, 2)
+		close(release)
+		assert.Equal(later, <-deadlines)
+
+		// Someone without a deadline lifts it altogether
+		release = make(chan struct{})
+		ctx, cancel = context.WithDeadline(context.Background(), soon)
+		defer cancel()
+		join(ctx, 1)
+		join(context.Background(), 2)
+		close(release)
+		assert.True((<-deadlines).IsZero())
+	})
+}
+
+func TestCoalescedRequests(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(time.RFC1123))
+		w.Write([]byte(`[{"alliance_id": 1354830081, "system_id": 30004759}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sov, err := client.GetSovMap(context.Background())
+			assert.NoError(err)
+			assert.Len(sov, 1)
+		}()
+	}
+
+	assert.Eventually(func() bool { return requests.Load() > 0 }, time.Second, time.Millisecond)
+	close(release) // Callers that missed the request find its result in the cache
+
+	wg.Wait()
+	assert.Equal(int32(1), requests.Load())
+}
@@ -0,0 +1,292 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	universe "IncursionBot/internal/Universe"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const buildWorkers int = maxConcurrentRequests // Enough to keep every request slot busy
+
+// Returned along with a universe that is missing whatever couldn't be fetched
+var ErrIncompleteUniverse = errors.New("some of the universe couldn't be fetched")
+
+// Answers universe lookups from the given universe instead of ESI, for every copy of the client. Systems it doesn't
+// have are still looked up on ESI.
+func (c *ESIClient) UseUniverse(graph *universe.Universe) {
+	if c.graph != nil {
+		c.graph.Store(graph)
+	}
+}
+
+// Gets the universe lookups are answered from, nil if there isn't one
+func (c *ESIClient) Universe() *universe.Universe {
+	if c.graph == nil {
+		return nil
+	}
+	return c.graph.Load()
+}
+
+func (c *ESIClient) universeName(id int) (string, bool) {
+	graph := c.Universe()
+	if graph == nil {
+		return "", false
+	}
+	return graph.Name(id)
+}
+
+func (c *ESIClient) universeConstellation(id int) (ConstellationData, bool) {
+	graph := c.Universe()
+	if graph == nil {
+		return ConstellationData{}, false
+	}
+
+	constellation, pres := graph.Constellations[id]
+	if !pres {
+		return ConstellationData{}, false
+	}
+
+	return ConstellationData{
+		ID:       constellation.ID,
+		Name:     constellation.Name,
+		RegionID: constellation.RegionID,
+		Systems:  constellation.Systems,
+	}, true
+}
+
+func (c *ESIClient) universeSystem(id int) (SystemData, bool) {
+	graph := c.Universe()
+	if graph == nil {
+		return SystemData{}, false
+	}
+
+	system, pres := graph.Systems[id]
+	if !pres {
+		return SystemData{}, false
+	}
+
+	result := SystemData{
+		ID:              system.ID,
+		Name:            system.Name,
+		SecStatus:       system.SecStatus,
+		ConstellationID: system.ConstellationID,
+		Position:        system.Position,
+		SecurityClass:   guessSecClass(system.SecStatus),
+	}
+	for _, gate := range system.Stargates {
+		result.Gates = append(result.Gates, gate.ID)
+	}
+	return result, true
+}
+
+func (c *ESIClient) universeStargates(systemID int) ([]StargateResponse, bool) {
+	graph := c.Universe()
+	if graph == nil {
+		return nil, false
+	}
+
+	system, pres := graph.Systems[systemID]
+	if !pres {
+		return nil, false
+	}
+
+	var result []StargateResponse
+	for _, gate := range system.Stargates {
+		var response StargateResponse
+		response.GateID = gate.ID
+		response.SystemID = systemID
+		response.Destination.GateID = gate.DestinationID
+		response.Destination.SystemID = gate.DestinationSystemID
+		if destination, pres := graph.Systems[gate.DestinationSystemID]; pres {
+			response.Name = "Stargate (" + destination.Name + ")"
+		}
+		result = append(result, response)
+	}
+	return result, true
+}
+
+func (c *ESIClient) universeRoute(from int, to int) []int {
+	graph := c.Universe()
+	if graph == nil {
+		return nil
+	}
+	return graph.Route(from, to)
+}
+
+// Builds the known space universe from ESI. This takes a request for every region, constellation, system and stargate,
+// so it's meant to be done once and saved. Anything that can't be fetched is left out, and the universe is returned along
+// with an ErrIncompleteUniverse saying how much is missing.
+func (c *ESIClient) BuildUniverse(ctx context.Context) (*universe.Universe, error) {
+	regions, err := fetchStatic[[]int](ctx, c, "/universe/regions/")
+	if err != nil {
+		return nil, err
+	}
+
+	result := universe.New(universe.SourceESI)
+	var mut sync.Mutex
+	var failed int
+
+	status, err := fetchStatic[ServerStatus](ctx, c, "/status/")
+	if err == nil {
+		result.Version = status.ServerVersion
+	}
+
+	var constellations []int
+	failed += forEach(ctx, regions, func(ctx context.Context, id int) error {
+		if !universe.KnownSpace(id) {
+			return nil
+		}
+
+		region, err := fetchStatic[RegionData](ctx, c, fmt.Sprintf("/universe/regions/%d/", id))
+		if err != nil {
+			return err
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		result.Regions[id] = &universe.Region{ID: id, Name: region.Name, Constellations: region.Constellations}
+		constellations = append(constellations, region.Constellations...)
+		return nil
+	})
+
+	var systems []int
+	failed += forEach(ctx, constellations, func(ctx context.Context, id int) error {
+		constellation, err := fetchStatic[ConstellationData](ctx, c, fmt.Sprintf("/universe/constellations/%d/", id))
+		if err != nil {
+			return err
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		result.Constellations[id] = &universe.Constellation{
+			ID:       id,
+			Name:     constellation.Name,
+			RegionID: constellation.RegionID,
+			Systems:  constellation.Systems,
+		}
+		systems = append(systems, constellation.Systems...)
+		return nil
+	})
+
+	var gates []int
+	failed += forEach(ctx, systems, func(ctx context.Context, id int) error {
+		system, err := fetchStatic[SystemData](ctx, c, fmt.Sprintf("/universe/systems/%d/", id))
+		if err != nil {
+			return err
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		result.Systems[id] = &universe.System{
+			ID:              id,
+			Name:            system.Name,
+			ConstellationID: system.ConstellationID,
+			SecStatus:       system.SecStatus,
+			Position:        system.Position,
+		}
+		if constellation, pres := result.Constellations[system.ConstellationID]; pres {
+			result.Systems[id].RegionID = constellation.RegionID
+		}
+		gates = append(gates, system.Gates...)
+		return nil
+	})
+
+	failed += forEach(ctx, gates, func(ctx context.Context, id int) error {
+		gate, err := fetchStatic[StargateResponse](ctx, c, fmt.Sprintf("/universe/stargates/%d/", id))
+		if err != nil {
+			return err
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		if system, pres := result.Systems[gate.SystemID]; pres {
+			system.Stargates = append(system.Stargates, universe.Stargate{
+				ID:                  id,
+				DestinationID:       gate.Destination.GateID,
+				DestinationSystemID: gate.Destination.SystemID,
+			})
+		}
+		return nil
+	})
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	for _, system := range result.Systems {
+		sort.Slice(system.Stargates, func(i, j int) bool { return system.Stargates[i].ID < system.Stargates[j].ID })
+	}
+	result.Prune()
+
+	if failed > 0 {
+		return result, fmt.Errorf("%w: %d requests failed", ErrIncompleteUniverse, failed)
+	}
+	return result, nil
+}
+
+// Calls fetch for every ID from a few workers, logging and skipping any that fail. Returns how many failed.
+func forEach(ctx context.Context, ids []int, fetch func(context.Context, int) error) int {
+	var failed atomic.Int32
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range buildWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				err := fetch(ctx, id)
+				if err == nil {
+					continue
+				}
+
+				failed.Add(1)
+				if ctx.Err() == nil {
+					logging.Warningf("Leaving %d out of the universe: %v", id, err)
+				}
+			}
+		}()
+	}
+
+send:
+	for _, id := range ids {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return int(failed.Load())
+}
+
+// Gets static data for building the universe. Unlike cachedCall nothing is kept, as it all ends up in the universe and
+// caching it would push everything else out of the cache and write a file to the disk cache for every item.
+func fetchStatic[T any](ctx context.Context, c *ESIClient, path string) (T, error) {
+	var result T
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return result, err
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("status code %d received from %s", resp.StatusCode, req.URL.Path)
+	}
+
+	err = c.parseResults(resp, &result)
+	return result, err
+}
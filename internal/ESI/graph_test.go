@@ -0,0 +1,125 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	universe "IncursionBot/internal/Universe"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildUniverse(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	responses := map[string]string{
+		"/status/":                           `{"players": 20000, "server_version": "2345678"}`,
+		"/route/30000142/30000142/":          `[30000142]`,
+		"/universe/regions/":                 `[10000002, 11000001]`,
+		"/universe/regions/10000002/":        `{"region_id": 10000002, "name": "The Forge", "constellations": [20000020]}`,
+		"/universe/constellations/20000020/": `{"constellation_id": 20000020, "name": "Kimotoro", "region_id": 10000002, "systems": [30000142, 30000144, 30000145]}`,
+		"/universe/systems/30000142/":        `{"system_id": 30000142, "name": "Jita", "constellation_id": 20000020, "security_status": 0.9459, "stargates": [50001248], "position": {"x": 1, "y": 2, "z": 3}}`,
+		"/universe/systems/30000144/":        `{"system_id": 30000144, "name": "Perimeter", "constellation_id": 20000020, "security_status": 0.9072, "stargates": [50001249, 50001250]}`,
+		"/universe/systems/30000145/":        `{"system_id": 30000145, "name": "New Caldari", "constellation_id": 20000020, "security_status": 0.95, "stargates": [50001251]}`,
+		"/universe/stargates/50001248/":      `{"stargate_id": 50001248, "name": "Stargate (Perimeter)", "system_id": 30000142, "destination": {"stargate_id": 50001249, "system_id": 30000144}}`,
+		"/universe/stargates/50001249/":      `{"stargate_id": 50001249, "name": "Stargate (Jita)", "system_id": 30000144, "destination": {"stargate_id": 50001248, "system_id": 30000142}}`,
+		"/universe/stargates/50001250/":      `{"stargate_id": 50001250, "name": "Stargate (New Caldari)", "system_id": 30000144, "destination": {"stargate_id": 50001251, "system_id": 30000145}}`,
+		"/universe/stargates/50001251/":      `{"stargate_id": 50001251, "name": "Stargate (Perimeter)", "system_id": 30000145, "destination": {"stargate_id": 50001250, "system_id": 30000144}}`,
+	}
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		response, pres := responses[r.URL.Path[len("/latest"):]]
+		if !pres {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL + "/latest"))
+	graph, err := client.BuildUniverse(context.Background())
+	assert.NoError(err)
+	assert.Equal(int32(1+1+1+1+3+4), requests.Load()) // Wormhole regions are never requested
+	assert.Equal("2345678", graph.Version)
+
+	assert.Len(graph.Regions, 1)
+	assert.Len(graph.Systems, 3)
+	assert.Equal(10000002, graph.Systems[30000142].RegionID)
+	assert.Equal(2, len(graph.Systems[30000144].Stargates))
+	assert.Equal([]int{30000142, 30000144, 30000145}, graph.Route(30000142, 30000145))
+	assert.Equal(0, client.CacheStats().Entries) // Everything is in the universe, so none of it is cached
+
+	t.Run("Lookups", func(t *testing.T) {
+		client := NewClient(WithBaseURL(server.URL + "/other"))
+		shared := client // Copies of a client share the universe
+		shared.UseUniverse(graph)
+		requests.Store(0)
+
+		distance, err := client.GetRouteLength(context.Background(), 30000142, 30000145)
+		assert.NoError(err)
+		assert.Equal(1, distance)
+
+		distance, err = client.GetRouteLength(context.Background(), 30000142, 30000142)
+		assert.NoError(err)
+		assert.Equal(-1, distance)
+
+		system, err := client.GetSystemInfo(context.Background(), 30000142)
+		assert.NoError(err)
+		assert.Equal("Jita", system.Name)
+		assert.Equal(HighSec, system.SecurityClass)
+		assert.Equal([]int{50001248}, system.Gates)
+
+		constellation, err := client.GetConstInfo(context.Background(), 20000020)
+		assert.NoError(err)
+		assert.Equal(10000002, constellation.RegionID)
+
+		gates, err := client.GetSystemConnections(context.Background(), 30000144)
+		assert.NoError(err)
+		assert.Len(gates, 2)
+		assert.Equal(30000144, gates[0].SystemID)
+		assert.Equal("Stargate (Jita)", gates[0].Name)
+
+		names, err := client.GetNames(context.Background(), []int{30000142, 20000020, 10000002})
+		assert.NoError(err)
+		assert.Equal(NameMap{30000142: "Jita", 20000020: "Kimotoro", 10000002: "The Forge"}, names)
+
+		assert.Equal(int32(0), requests.Load())
+
+		// Anything outside the universe still goes to ESI
+		_, err = client.GetSystemInfo(context.Background(), 31000005)
+		assert.Error(err)
+		assert.Equal(int32(1), requests.Load())
+	})
+
+	t.Run("Same system", func(t *testing.T) {
+		// ESI's answer for a route from a system to itself, which the universe has to match
+		client := NewClient(WithBaseURL(server.URL + "/latest"))
+		distance, err := client.GetRouteLength(context.Background(), 30000142, 30000142)
+		assert.NoError(err)
+		assert.Equal(-1, distance)
+
+		client.UseUniverse(graph)
+		distance, err = client.GetRouteLength(context.Background(), 30000142, 30000142)
+		assert.NoError(err)
+		assert.Equal(-1, distance)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		delete(responses, "/universe/systems/30000145/")
+		client := NewClient(WithBaseURL(server.URL+"/latest"), WithRetries(1, 0))
+		graph, err := client.BuildUniverse(context.Background())
+		assert.ErrorIs(err, ErrIncompleteUniverse)
+
+		// The rest is still built, without the gate to the missing system
+		assert.Len(graph.Systems, 2)
+		assert.Equal([]universe.Stargate{{ID: 50001249, DestinationID: 50001248, DestinationSystemID: 30000142}}, graph.Systems[30000144].Stargates)
+		assert.Nil(graph.Route(30000142, 30000145))
+	})
+}
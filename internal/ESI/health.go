@@ -0,0 +1,245 @@
+package ESI
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const latencyWeight float64 = 0.2 // How much each request moves the average latency
+
+// Overall health of ESI, from best to worst
+type HealthLevel string
+
+const (
+	HealthGood     HealthLevel = "good"
+	HealthDegraded HealthLevel = "degraded" // Some endpoints the bot uses are slow or failing
+	HealthDown     HealthLevel = "down"     // ESI or Tranquility can't be reached
+)
+
+// Tranquility's status as reported by ESI
+type ServerStatus struct {
+	Players       int       `json:"players"`
+	ServerVersion string    `json:"server_version"`
+	StartTime     time.Time `json:"start_time"`
+	VIP           bool      `json:"vip"` // Only developers can log in
+}
+
+// ESI's own view of one of its routes
+type metaRouteStatus struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	Status string `json:"status"` // green, yellow or red
+}
+
+// How an endpoint has been doing, from the bot's own requests and ESI's meta status
+type RouteHealth struct {
+	Endpoint       string        `json:"endpoint"`
+	Requests       uint64        `json:"requests"`
+	Errors         uint64        `json:"errors"` // Failed requests and 5xx responses
+	AverageLatency time.Duration `json:"averageLatency"`
+	LastStatus     int           `json:"lastStatus,omitempty"` // Status code of the last response, unset if the last request failed
+	LastSuccess    *time.Time    `json:"lastSuccess,omitempty"`
+	Breaker        BreakerState  `json:"breaker"`
+	MetaStatus     string        `json:"metaStatus,omitempty"` // ESI's own status for the route, green, yellow or red
+}
+
+// ESI health as of the last check, along with every endpoint the bot has used
+type Health struct {
+	Level     HealthLevel     `json:"level"`
+	Server    *ServerStatus   `json:"server,omitempty"` // Unset if the last check couldn't get it
+	CheckedAt time.Time       `json:"checkedAt"`
+	Error     string          `json:"error,omitempty"` // Why the last check failed
+	Routes    []RouteHealth   `json:"routes"`
+	Contract  *ContractReport `json:"contract,omitempty"` // Last check against ESI's swagger spec, unset if there hasn't been one
+}
+
+type routeStats struct {
+	requests    uint64
+	errors      uint64
+	latency     time.Duration
+	lastStatus  int
+	lastSuccess time.Time
+}
+
+// Tracks ESI's health from its status endpoints and the bot's own traffic. Shared by copies of a client.
+type healthMonitor struct {
+	mut       sync.Mutex
+	routes    map[string]*routeStats
+	meta      map[string]string // Meta status by endpoint name
+	server    *ServerStatus
+	checkedAt time.Time
+	checkErr  error
+	contract  *ContractReport
+}
+
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{routes: make(map[string]*routeStats), meta: make(map[string]string)}
+}
+
+// Records how a request to the endpoint went
+func (h *healthMonitor) record(endpoint string, latency time.Duration, resp *http.Response, err error) {
+	if h == nil {
+		return
+	}
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	stats, pres := h.routes[endpoint]
+	if !pres {
+		stats = &routeStats{latency: latency}
+		h.routes[endpoint] = stats
+	}
+
+	stats.requests++
+	stats.latency += time.Duration(latencyWeight * float64(latency-stats.latency))
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		stats.errors++
+	}
+
+	stats.lastStatus = 0
+	if err == nil {
+		stats.lastStatus = resp.StatusCode
+		if resp.StatusCode < http.StatusBadRequest {
+			stats.lastSuccess = time.Now()
+		}
+	}
+}
+
+// Checks ESI's status and meta status endpoints, keeping the results for Health
+func (c *ESIClient) CheckHealth(ctx context.Context) error {
+	var server ServerStatus
+	var meta []metaRouteStatus
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/status/", nil)
+	if err == nil {
+		err = c.getJSON(req, &server)
+	}
+	if err == nil {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.metaStatusURL(), nil)
+	}
+	if err == nil {
+		err = c.getJSON(req, &meta)
+	}
+
+	if c.health == nil {
+		return err
+	}
+
+	c.health.mut.Lock()
+	defer c.health.mut.Unlock()
+
+	c.health.checkedAt = time.Now()
+	c.health.checkErr = err
+	if err != nil {
+		c.health.server = nil
+		return err
+	}
+
+	c.health.server = &server
+	c.health.meta = make(map[string]string)
+	for _, route := range meta {
+		c.health.meta[metaEndpointName(route)] = route.Status
+	}
+
+	return nil
+}
+
+// Sends the request and parses the JSON response, without caching or retrying it
+func (c *ESIClient) getJSON(req *http.Request, result any) error {
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d received from %s", resp.StatusCode, req.URL.Path)
+	}
+
+	return c.parseResults(resp, result)
+}
+
+// The meta status lives outside of the versioned routes, e.g. https://esi.evetech.net/status.json?version=latest
+func (c *ESIClient) metaStatusURL() string {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return c.baseURL + "/status.json"
+	}
+
+	version := base.Path[strings.LastIndex(base.Path, "/")+1:]
+	base.Path = strings.TrimSuffix(base.Path, version) + "status.json"
+	base.RawQuery = url.Values{"version": {version}}.Encode()
+	return base.String()
+}
+
+var routeParameter = regexp.MustCompile(`\{[^}]*\}`)
+
+// Names a meta status route the same way as the bot's own requests, e.g. /universe/systems/{system_id}/ becomes
+// GET /universe/systems/{id}/
+func metaEndpointName(route metaRouteStatus) string {
+	return strings.ToUpper(route.Method) + " " + routeParameter.ReplaceAllString(route.Route, "{id}")
+}
+
+// Gets ESI's health as of the last check, with every endpoint the bot has used
+func (c *ESIClient) Health() Health {
+	if c.health == nil {
+		return Health{Level: HealthGood}
+	}
+
+	breakers := make(map[string]BreakerState)
+	for _, endpoint := range c.Endpoints() {
+		breakers[endpoint.Endpoint] = endpoint.State
+	}
+
+	c.health.mut.Lock()
+	defer c.health.mut.Unlock()
+
+	health := Health{Level: HealthGood, CheckedAt: c.health.checkedAt}
+	if c.health.server != nil {
+		server := *c.health.server
+		health.Server = &server
+	}
+	if c.health.contract != nil {
+		contract := *c.health.contract
+		health.Contract = &contract
+	}
+	if c.health.checkErr != nil {
+		health.Error = c.health.checkErr.Error()
+		health.Level = HealthDown
+	}
+
+	for endpoint, stats := range c.health.routes {
+		route := RouteHealth{
+			Endpoint:       endpoint,
+			Requests:       stats.requests,
+			Errors:         stats.errors,
+			AverageLatency: stats.latency,
+			LastStatus:     stats.lastStatus,
+			Breaker:        BreakerClosed,
+			MetaStatus:     c.health.meta[endpoint],
+		}
+		if !stats.lastSuccess.IsZero() {
+			lastSuccess := stats.lastSuccess
+			route.LastSuccess = &lastSuccess
+		}
+		if state, pres := breakers[endpoint]; pres {
+			route.Breaker = state
+		}
+
+		if health.Level == HealthGood && (route.Breaker != BreakerClosed || route.MetaStatus == "yellow" || route.MetaStatus == "red") {
+			health.Level = HealthDegraded
+		}
+		health.Routes = append(health.Routes, route)
+	}
+
+	sort.Slice(health.Routes, func(i, j int) bool { return health.Routes[i].Endpoint < health.Routes[j].Endpoint })
+	return health
+}
@@ -0,0 +1,90 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	systemStatus := "green"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/status/":
+			w.Write([]byte(`{"players": 23456, "server_version": "2234567", "start_time": "2022-03-01T11:05:00Z"}`))
+		case "/status.json":
+			assert.Equal("latest", r.URL.Query().Get("version"))
+			w.Write([]byte(`[{"method": "get", "route": "/universe/systems/{system_id}/", "status": "` + systemStatus + `"},
+				{"method": "get", "route": "/incursions/", "status": "green"}]`))
+		case "/latest/universe/systems/30000142/":
+			w.Write([]byte(`{"system_id": 30000142, "name": "Jita"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL + "/latest"))
+	assert.Equal(server.URL+"/status.json?version=latest", client.metaStatusURL())
+
+	_, err := client.GetSystemInfo(context.Background(), 30000142)
+	assert.NoError(err)
+	assert.NoError(client.CheckHealth(context.Background()))
+
+	health := client.Health()
+	assert.Equal(HealthGood, health.Level)
+	assert.Equal(23456, health.Server.Players)
+	assert.False(health.CheckedAt.IsZero())
+
+	// Only endpoints the bot uses are listed, including the status checks
+	var system RouteHealth
+	for _, route := range health.Routes {
+		if route.Endpoint == "GET /universe/systems/{id}/" {
+			system = route
+		}
+	}
+	assert.Equal(uint64(1), system.Requests)
+	assert.Equal(http.StatusOK, system.LastStatus)
+	assert.Equal("green", system.MetaStatus)
+	assert.Equal(BreakerClosed, system.Breaker)
+	assert.NotNil(system.LastSuccess)
+
+	t.Run("Degraded", func(t *testing.T) {
+		systemStatus = "red"
+		assert.NoError(client.CheckHealth(context.Background()))
+		assert.Equal(HealthDegraded, client.Health().Level)
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		down := NewClient(WithBaseURL(server.URL + "/other"))
+		assert.Error(down.CheckHealth(context.Background()))
+
+		health := down.Health()
+		assert.Equal(HealthDown, health.Level)
+		assert.Nil(health.Server)
+		assert.NotEmpty(health.Error)
+	})
+}
+
+func TestRouteStats(t *testing.T) {
+	assert := assert.New(t)
+	monitor := newHealthMonitor()
+
+	monitor.record("GET /incursions/", time.Millisecond*100, &http.Response{StatusCode: http.StatusOK}, nil)
+	monitor.record("GET /incursions/", time.Millisecond*200, &http.Response{StatusCode: http.StatusBadGateway}, nil)
+	monitor.record("GET /incursions/", time.Second, nil, context.DeadlineExceeded)
+
+	stats := monitor.routes["GET /incursions/"]
+	assert.Equal(uint64(3), stats.requests)
+	assert.Equal(uint64(2), stats.errors)
+	assert.Equal(0, stats.lastStatus)
+	assert.Equal(time.Millisecond*100+time.Millisecond*20+time.Millisecond*176, stats.latency)
+}
@@ -2,6 +2,7 @@ package ESI
 
 import (
 	logging "IncursionBot/internal/Logging"
+	"context"
 	"net/http"
 	"time"
 )
@@ -14,21 +15,24 @@ type IncursionResponse struct {
 	State            string  `json:"state"`
 }
 
-var incursionsCache CacheEntry
-
-func (c *ESIClient) GetIncursions() ([]IncursionResponse, time.Time, error) {
-	var result []IncursionResponse
-	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/incursions/", nil)
+func (c *ESIClient) GetIncursions(ctx context.Context) ([]IncursionResponse, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/incursions/", nil)
 	if err != nil {
 		logging.Errorln("Failed to create request for incursions", err)
-		return result, incursionsCache.ExpirationTime, err
+		return nil, time.Time{}, err
 	}
-	err = c.cachedCall(req, &incursionsCache, &result)
 
+	entry, err := cachedCall[[]IncursionResponse](c, req, false)
 	if err != nil {
 		logging.Errorln("Error occured while getting incursions", err)
-		return result, incursionsCache.ExpirationTime, err
+		return entry.Data, entry.ExpirationTime, err
 	}
 
-	return result, incursionsCache.ExpirationTime, nil
+	return entry.Data, entry.ExpirationTime, nil
+}
+
+// Gets when ESI last changed the incursion data the bot has, zero if it has none
+func (c *ESIClient) IncursionsModified() time.Time {
+	entry, _ := cachePeek[[]IncursionResponse](c.cache, requestKey(http.MethodGet, c.baseURL+"/incursions/"))
+	return entry.Modified
 }
@@ -0,0 +1,88 @@
+package ESI
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultUserAgent string = "IncursionBot"
+const defaultTimeout time.Duration = time.Second * 30
+
+// Changes how a new client talks to ESI
+type ClientOption func(*ESIClient)
+
+// Sends requests to the given URL instead of ESI, e.g. a caching proxy or a local stand-in. The URL includes the version.
+func WithBaseURL(url string) ClientOption {
+	return func(c *ESIClient) {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// Makes requests with the given HTTP client, or the default one if nil. Later transport and timeout options apply to a
+// copy of it.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *ESIClient) {
+		if client == nil {
+			client = &http.Client{Timeout: defaultTimeout}
+		}
+		c.httpClient = client
+	}
+}
+
+// Makes requests through the given transport
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *ESIClient) {
+		client := c.copyHTTPClient()
+		client.Transport = transport
+		c.httpClient = client
+	}
+}
+
+// Gives up on requests that take longer than the timeout, 30 seconds by default
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *ESIClient) {
+		client := c.copyHTTPClient()
+		client.Timeout = timeout
+		c.httpClient = client
+	}
+}
+
+// Copies the HTTP client so options never change one passed to WithHTTPClient
+func (c *ESIClient) copyHTTPClient() *http.Client {
+	if c.httpClient == nil {
+		return &http.Client{Timeout: defaultTimeout}
+	}
+
+	client := *c.httpClient
+	return &client
+}
+
+// Identifies the bot to ESI. CCP asks for contact details in case the bot misbehaves.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *ESIClient) {
+		c.userAgent = userAgent
+	}
+}
+
+// Tries requests that fail for transient reasons up to the given number of times in total, waiting about the base delay before
+// the first retry and twice as long before each one after. One attempt disables retrying.
+func WithRetries(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(c *ESIClient) {
+		c.retries = &retryPolicy{maxAttempts: maxAttempts, baseDelay: baseDelay}
+	}
+}
+
+// Stops calling an endpoint for the cooldown once it fails the given number of times in a row
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *ESIClient) {
+		c.breakers = newBreakerSet(threshold, cooldown)
+	}
+}
+
+// Keeps up to the given number of responses in memory
+func WithCacheSize(entries int) ClientOption {
+	return func(c *ESIClient) {
+		c.cache = NewCache(entries)
+	}
+}
@@ -0,0 +1,90 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientOptions(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	var userAgent, path string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		path = r.URL.Path
+		w.Write([]byte("[30000142, 30000144, 30002187]"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL+"/latest/"), WithUserAgent("test-bot"), WithTimeout(time.Second))
+	assert.Equal(time.Second, client.httpClient.Timeout)
+
+	length, err := client.GetRouteLength(context.Background(), 30000142, 30002187)
+	assert.NoError(err)
+	assert.Equal(1, length)
+	assert.Equal("/latest/route/30000142/30002187/", path)
+	assert.Equal("test-bot", userAgent)
+
+	t.Run("Transport", func(t *testing.T) {
+		base := &http.Client{}
+		var requested string
+		client := NewClient(WithHTTPClient(base), WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requested = req.URL.String()
+			return server.Client().Transport.RoundTrip(req)
+		})), WithBaseURL(server.URL))
+
+		_, err := client.GetRouteLength(context.Background(), 1, 2)
+		assert.NoError(err)
+		assert.Equal(server.URL+"/route/1/2/", requested)
+		assert.Equal(defaultUserAgent, userAgent)
+		assert.Nil(base.Transport) // The given client is left alone
+	})
+
+	t.Run("Nil client", func(t *testing.T) {
+		client := NewClient(WithHTTPClient(nil), WithTimeout(time.Second), WithBaseURL(server.URL))
+		assert.Equal(time.Second, client.httpClient.Timeout)
+
+		client = ESIClient{}
+		WithTransport(server.Client().Transport)(&client)
+		assert.Equal(defaultTimeout, client.httpClient.Timeout)
+
+		client = NewClient(WithHTTPClient(nil), WithBaseURL(server.URL))
+		_, err := client.GetRouteLength(context.Background(), 1, 2)
+		assert.NoError(err)
+	})
+}
+
+func TestRequestCancelled(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(WithBaseURL(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetSystemInfo(ctx, 30000142)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Less(time.Since(start), time.Second)
+	assert.Eventually(func() bool { return client.ErrorBudget().InFlight == 0 }, time.Second, time.Millisecond*10)
+}
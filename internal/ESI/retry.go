@@ -0,0 +1,325 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"IncursionBot/internal/Utils"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMaxAttempts int = 3
+const defaultRetryDelay time.Duration = time.Second // Doubled after each attempt
+const maxRetryDelay time.Duration = time.Second * 30
+
+const defaultBreakerThreshold int = 5                    // Failures in a row that open an endpoint's circuit breaker
+const defaultBreakerCooldown time.Duration = time.Minute // How long an open breaker fails requests before letting one through
+
+// Returned instead of calling an endpoint that has been failing
+var ErrCircuitOpen = errors.New("ESI endpoint is failing, not calling it")
+
+// How requests that fail for transient reasons are retried
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// Sends an idempotent request, retrying transient failures with exponential backoff unless the endpoint's breaker is open
+func (c *ESIClient) send(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	endpoint := c.endpointName(req)
+
+	policy := retryPolicy{maxAttempts: 1}
+	if c.retries != nil {
+		policy = *c.retries
+	}
+
+	delay := policy.baseDelay
+	for attempt := 1; ; attempt++ {
+		if !c.breakers.allow(endpoint) {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, endpoint)
+		}
+
+		resp, err := c.do(req)
+		if ctx.Err() != nil {
+			c.breakers.abandon(endpoint) // Cancelled, ESI isn't to blame
+			return resp, err
+		}
+		c.breakers.record(endpoint, err == nil && resp.StatusCode < http.StatusInternalServerError)
+
+		if !retryable(resp, err) {
+			return resp, err
+		}
+
+		// Waiting less than ESI asked would only fail again, so if there isn't time to wait that long the endpoint is left
+		// alone until then
+		wait := retryAfter(resp)
+		deadline, hasDeadline := ctx.Deadline()
+		if wait > 0 && (wait > maxRetryDelay || attempt >= policy.maxAttempts || (hasDeadline && time.Until(deadline) < wait)) {
+			c.breakers.pause(endpoint, wait)
+			return resp, err
+		}
+		if attempt >= policy.maxAttempts {
+			return resp, err
+		}
+
+		if wait <= 0 {
+			wait = delay/2 + rand.N(delay/2+1) // Jitter keeps the bot's goroutines from retrying in lockstep
+			wait = min(wait, maxRetryDelay)
+		}
+		delay *= 2
+
+		if err != nil {
+			logging.Warningf("Request to %s failed, retrying in %s: %v", endpoint, wait.Round(time.Millisecond), err)
+		} else {
+			logging.Warningf("%s returned %d, retrying in %s", endpoint, resp.StatusCode, wait.Round(time.Millisecond))
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if Utils.SleepContext(ctx, wait) != nil {
+			return nil, ctx.Err()
+		}
+
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Checks if a request could succeed if tried again
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// How long the server asked us to wait before trying again, zero if it didn't say
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	header := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// Gets a copy of the request that can be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+
+	return next, nil
+}
+
+// Names the endpoint the request is for, with IDs replaced so every system shares a breaker, e.g. /universe/systems/{id}/
+func (c *ESIClient) endpointName(req *http.Request) string {
+	path := req.URL.Path
+	if base, err := url.Parse(c.baseURL); err == nil {
+		path = strings.TrimPrefix(path, base.Path)
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+
+	return req.Method + " " + strings.Join(segments, "/")
+}
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"   // Requests go through as normal
+	BreakerOpen     BreakerState = "open"     // Requests fail without being sent
+	BreakerHalfOpen BreakerState = "halfOpen" // A single request is being let through to see if the endpoint recovered
+)
+
+// Circuit breaker state of an endpoint that has been called
+type EndpointStatus struct {
+	Endpoint string       `json:"endpoint"`
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`          // Failures in a row
+	RetryAt  *time.Time   `json:"retryAt,omitempty"` // When an open breaker next lets a request through
+}
+
+type breaker struct {
+	state    BreakerState
+	failures int
+	retryAt  time.Time
+}
+
+// Circuit breakers for every endpoint, shared by copies of a client
+type breakerSet struct {
+	threshold int
+	cooldown  time.Duration
+
+	mut      sync.Mutex
+	breakers map[string]*breaker
+	now      func() time.Time
+}
+
+func newBreakerSet(threshold int, cooldown time.Duration) *breakerSet {
+	return &breakerSet{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*breaker),
+		now:       time.Now,
+	}
+}
+
+// Checks if a request to the endpoint may be sent. Once an open breaker's cooldown passes a single request is allowed.
+func (set *breakerSet) allow(endpoint string) bool {
+	if set == nil {
+		return true
+	}
+
+	set.mut.Lock()
+	defer set.mut.Unlock()
+
+	b := set.get(endpoint)
+	switch b.state {
+	case BreakerOpen:
+		if set.now().Before(b.retryAt) {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	case BreakerHalfOpen:
+		return false // Still waiting to hear how the trial request went
+	default:
+		return true
+	}
+}
+
+// Records how a request to the endpoint went
+func (set *breakerSet) record(endpoint string, success bool) {
+	if set == nil {
+		return
+	}
+
+	set.mut.Lock()
+	defer set.mut.Unlock()
+
+	b := set.get(endpoint)
+	if success {
+		if b.state != BreakerClosed {
+			logging.Infof("%s is working again, closing its circuit breaker", endpoint)
+		}
+		*b = breaker{state: BreakerClosed}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= set.threshold {
+		if b.state != BreakerOpen {
+			logging.Warningf("%s has failed %d times in a row, not calling it for %s", endpoint, b.failures, set.cooldown)
+		}
+		b.state = BreakerOpen
+		b.retryAt = set.now().Add(set.cooldown)
+	}
+}
+
+// Fails requests to the endpoint without sending them until ESI's Retry-After has passed
+func (set *breakerSet) pause(endpoint string, wait time.Duration) {
+	if set == nil {
+		return
+	}
+
+	set.mut.Lock()
+	defer set.mut.Unlock()
+
+	b := set.get(endpoint)
+	retryAt := set.now().Add(wait)
+	if b.state == BreakerOpen && b.retryAt.After(retryAt) {
+		return
+	}
+
+	logging.Warningf("%s asked for %s before trying again, not calling it until then", endpoint, wait.Round(time.Second))
+	b.state = BreakerOpen
+	b.retryAt = retryAt
+}
+
+// Lets another request through if a trial request was given up on before ESI answered
+func (set *breakerSet) abandon(endpoint string) {
+	if set == nil {
+		return
+	}
+
+	set.mut.Lock()
+	defer set.mut.Unlock()
+
+	b := set.get(endpoint)
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+		b.retryAt = set.now()
+	}
+}
+
+func (set *breakerSet) get(endpoint string) *breaker {
+	b, pres := set.breakers[endpoint]
+	if !pres {
+		b = &breaker{state: BreakerClosed}
+		set.breakers[endpoint] = b
+	}
+
+	return b
+}
+
+func (set *breakerSet) status() []EndpointStatus {
+	if set == nil {
+		return nil
+	}
+
+	set.mut.Lock()
+	defer set.mut.Unlock()
+
+	var result []EndpointStatus
+	for endpoint, b := range set.breakers {
+		status := EndpointStatus{Endpoint: endpoint, State: b.state, Failures: b.failures}
+		if b.state == BreakerOpen {
+			retryAt := b.retryAt
+			status.RetryAt = &retryAt
+		}
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Endpoint < result[j].Endpoint })
+	return result
+}
+
+// Gets the circuit breaker state of every endpoint called so far
+func (c *ESIClient) Endpoints() []EndpointStatus {
+	return c.breakers.status()
+}
@@ -0,0 +1,193 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetries(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	var requests int
+	var bodies []string
+	statuses := []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}
+	wait := "0"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		status := statuses[min(requests, len(statuses)-1)]
+		requests++
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", wait)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`[{"category": "region", "id": 10000060, "name": "Delve"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithRetries(3, time.Millisecond))
+	names, err := client.GetNames(context.Background(), []int{10000060})
+	assert.NoError(err)
+	assert.Equal("Delve", names[10000060])
+	assert.Equal(3, requests)
+	assert.Equal([]string{"[10000060]", "[10000060]", "[10000060]"}, bodies) // The POST body is sent every time
+
+	t.Run("Gives up", func(t *testing.T) {
+		requests = 0
+		statuses = []int{http.StatusServiceUnavailable}
+
+		_, err := client.GetRouteLength(context.Background(), 1, 2)
+		assert.Error(err)
+		assert.Equal(3, requests)
+	})
+
+	t.Run("Client errors aren't retried", func(t *testing.T) {
+		requests = 0
+		statuses = []int{http.StatusNotFound}
+
+		_, err := client.GetSystemInfo(context.Background(), 1)
+		assert.Error(err)
+		assert.Equal(1, requests)
+	})
+
+	t.Run("Long Retry-After", func(t *testing.T) {
+		requests = 0
+		statuses = []int{http.StatusTooManyRequests}
+		wait = "120"
+
+		_, err := client.GetSystemInfo(context.Background(), 2)
+		assert.Error(err)
+		assert.Equal(1, requests) // Longer than the bot ever waits, so not retried
+
+		// The endpoint is left alone until ESI said to come back
+		_, err = client.GetSystemInfo(context.Background(), 3)
+		assert.ErrorIs(err, ErrCircuitOpen)
+		assert.Equal(1, requests)
+
+		for _, status := range client.Endpoints() {
+			if status.Endpoint == "GET /universe/systems/{id}/" {
+				assert.Equal(BreakerOpen, status.State)
+				assert.WithinDuration(time.Now().Add(time.Minute*2), *status.RetryAt, time.Second*2)
+			}
+		}
+	})
+
+	t.Run("Retry-After past the deadline", func(t *testing.T) {
+		requests = 0
+		statuses = []int{http.StatusTooManyRequests}
+		wait = "10"
+		client := NewClient(WithBaseURL(server.URL), WithRetries(3, time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := client.GetSystemInfo(ctx, 4)
+		assert.Error(err)
+		assert.Equal(1, requests)
+		assert.NoError(ctx.Err()) // Gave up straight away instead of waiting out the timeout
+
+		_, err = client.GetSystemInfo(context.Background(), 5)
+		assert.ErrorIs(err, ErrCircuitOpen) // Left alone until ESI said to come back
+		assert.Equal(1, requests)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	resp := &http.Response{Header: make(http.Header)}
+	assert.Equal(time.Duration(0), retryAfter(resp))
+	assert.Equal(time.Duration(0), retryAfter(nil))
+
+	resp.Header.Set("Retry-After", "7")
+	assert.Equal(time.Second*7, retryAfter(resp))
+
+	resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.InDelta(time.Minute, retryAfter(resp), float64(time.Second*2))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	breakers := newBreakerSet(2, time.Minute)
+	breakers.now = func() time.Time { return now }
+	const endpoint = "GET /universe/systems/{id}/"
+
+	assert.True(breakers.allow(endpoint))
+	breakers.record(endpoint, false)
+	assert.True(breakers.allow(endpoint))
+	breakers.record(endpoint, false)
+
+	// Open after enough failures in a row
+	assert.False(breakers.allow(endpoint))
+	status := breakers.status()
+	assert.Equal(BreakerOpen, status[0].State)
+	assert.Equal(now.Add(time.Minute), *status[0].RetryAt)
+
+	t.Run("Half open", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		assert.True(breakers.allow(endpoint))
+		assert.False(breakers.allow(endpoint)) // Only one trial request
+
+		breakers.record(endpoint, false)
+		assert.False(breakers.allow(endpoint))
+
+		now = now.Add(time.Minute)
+		assert.True(breakers.allow(endpoint))
+		breakers.abandon(endpoint)
+		assert.True(breakers.allow(endpoint))
+
+		breakers.record(endpoint, true)
+		assert.Equal(BreakerClosed, breakers.status()[0].State)
+		assert.Equal(0, breakers.status()[0].Failures)
+	})
+
+	t.Run("Serves cached data while open", func(t *testing.T) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				successfulReturn(w, r)
+			} else {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		defer server.Close()
+
+		client := NewClient(WithBaseURL(server.URL+"/latest"), WithRetries(1, 0), WithCircuitBreaker(1, time.Minute))
+		key := "GET " + server.URL + "/latest/route/1/2/"
+		get := func() (CacheEntry[int], error) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/latest/route/1/2/", nil)
+			return cachedCall[int](&client, req, false)
+		}
+
+		_, err := get()
+		assert.NoError(err)
+
+		entry, _ := cacheGet[int](client.cache, key)
+		entry.ExpirationTime = time.Time{}
+		cacheSet(client.cache, key, entry)
+
+		for i := 0; i < 3; i++ {
+			entry, err := get()
+			assert.NoError(err)
+			assert.Equal(testReturn, entry.Data)
+		}
+		assert.Equal(2, requests)
+
+		endpoints := client.Endpoints()
+		assert.Equal("GET /route/{id}/{id}/", endpoints[0].Endpoint)
+		assert.Equal(BreakerOpen, endpoints[0].State)
+	})
+}
@@ -0,0 +1,247 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// An endpoint the bot uses, and the type its successful responses are parsed into
+type contract struct {
+	method   string
+	route    string // As written in the swagger spec
+	response reflect.Type
+}
+
+var contracts = []contract{
+	{http.MethodGet, "/incursions/", reflect.TypeFor[[]IncursionResponse]()},
+	{http.MethodGet, "/alliances/{alliance_id}/", reflect.TypeFor[AllianceDetailResponse]()},
+	{http.MethodPost, "/universe/names/", reflect.TypeFor[[]NameResponse]()},
+	{http.MethodGet, "/universe/regions/", reflect.TypeFor[[]int]()},
+	{http.MethodGet, "/universe/regions/{region_id}/", reflect.TypeFor[RegionData]()},
+	{http.MethodGet, "/universe/constellations/{constellation_id}/", reflect.TypeFor[ConstellationData]()},
+	{http.MethodGet, "/universe/systems/{system_id}/", reflect.TypeFor[SystemData]()},
+	{http.MethodGet, "/route/{origin}/{destination}/", reflect.TypeFor[Route]()},
+	{http.MethodGet, "/universe/stargates/{stargate_id}/", reflect.TypeFor[StargateResponse]()},
+	{http.MethodGet, "/sovereignty/map/", reflect.TypeFor[[]SovResponse]()},
+	{http.MethodGet, "/status/", reflect.TypeFor[ServerStatus]()},
+}
+
+// Something in ESI's spec that no longer matches what the bot expects
+type ContractIssue struct {
+	Endpoint string `json:"endpoint"`
+	Field    string `json:"field,omitempty"` // Path to the field, e.g. destination.system_id, unset for the route itself
+	Problem  string `json:"problem"`
+}
+
+// Result of checking the endpoints the bot uses against ESI's swagger spec
+type ContractReport struct {
+	CheckedAt      time.Time       `json:"checkedAt"`
+	SwaggerVersion string          `json:"swaggerVersion,omitempty"`
+	Issues         []ContractIssue `json:"issues"`
+	Error          string          `json:"error,omitempty"` // Why the spec couldn't be checked
+}
+
+type swaggerSpec struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths       map[string]map[string]json.RawMessage `json:"paths"` // Operations by route and method, alongside shared parameters
+	Definitions map[string]*swaggerSchema             `json:"definitions"`
+}
+
+type swaggerOperation struct {
+	Deprecated bool `json:"deprecated"`
+	Responses  map[string]struct {
+		Schema *swaggerSchema `json:"schema"`
+	} `json:"responses"`
+}
+
+type swaggerSchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Format     string                    `json:"format"`
+	Items      *swaggerSchema            `json:"items"`
+	Properties map[string]*swaggerSchema `json:"properties"`
+}
+
+// Downloads ESI's swagger spec and checks every endpoint the bot uses against it, keeping the report for Health
+func (c *ESIClient) CheckSwagger(ctx context.Context) (ContractReport, error) {
+	report := ContractReport{CheckedAt: time.Now()}
+
+	var spec swaggerSpec
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/swagger.json", nil)
+	if err == nil {
+		err = c.getJSON(req, &spec)
+	}
+
+	if err != nil {
+		logging.Errorln("Failed to get the ESI swagger spec", err)
+		report.Error = err.Error()
+	} else {
+		report.SwaggerVersion = spec.Info.Version
+		report.Issues = spec.check(contracts)
+	}
+
+	if c.health != nil {
+		c.health.mut.Lock()
+		c.health.contract = &report
+		c.health.mut.Unlock()
+	}
+
+	return report, err
+}
+
+// Finds every way the spec differs from what the contracts expect
+func (spec *swaggerSpec) check(contracts []contract) []ContractIssue {
+	var issues []ContractIssue
+	for _, contract := range contracts {
+		endpoint := metaEndpointName(metaRouteStatus{Method: contract.method, Route: contract.route})
+		report := func(field string, problem string, args ...any) {
+			issues = append(issues, ContractIssue{Endpoint: endpoint, Field: field, Problem: fmt.Sprintf(problem, args...)})
+		}
+
+		raw, pres := spec.Paths[contract.route][strings.ToLower(contract.method)]
+		if !pres {
+			report("", "route removed")
+			continue
+		}
+
+		var operation swaggerOperation
+		if err := json.Unmarshal(raw, &operation); err != nil {
+			report("", "unreadable operation: %v", err)
+			continue
+		}
+		if operation.Deprecated {
+			report("", "route deprecated")
+		}
+
+		response, pres := operation.Responses["200"]
+		if !pres || response.Schema == nil {
+			report("", "no schema for successful responses")
+			continue
+		}
+
+		spec.compare(contract.response, response.Schema, "", report)
+	}
+
+	return issues
+}
+
+// Compares a Go type with the schema it is parsed from, reporting each field that is missing or has changed type
+func (spec *swaggerSpec) compare(goType reflect.Type, schema *swaggerSchema, field string, report func(string, string, ...any)) {
+	schema = spec.resolve(schema)
+	if schema == nil {
+		report(field, "unresolvable schema reference")
+		return
+	}
+
+	expected := schemaType(goType)
+	if expected == "" {
+		return
+	}
+	if schema.Type != expected && !(expected == "number" && schema.Type == "integer") {
+		report(field, "type changed from %s to %s", expected, schema.Type)
+		return
+	}
+
+	switch expected {
+	case "array":
+		if schema.Items == nil {
+			report(field, "array has no item schema")
+			return
+		}
+		spec.compare(goType.Elem(), schema.Items, field, report)
+	case "object":
+		for i := 0; i < goType.NumField(); i++ {
+			name, ok := jsonName(goType.Field(i))
+			if !ok {
+				continue
+			}
+
+			path := name
+			if field != "" {
+				path = field + "." + name
+			}
+
+			property := findProperty(schema.Properties, name)
+			if property == nil {
+				report(path, "field removed")
+				continue
+			}
+			spec.compare(goType.Field(i).Type, property, path, report)
+		}
+	}
+}
+
+// Follows a reference to one of the spec's definitions
+func (spec *swaggerSpec) resolve(schema *swaggerSchema) *swaggerSchema {
+	for depth := 0; schema != nil && schema.Ref != "" && depth < 10; depth++ {
+		schema = spec.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]
+	}
+	if schema != nil && schema.Ref != "" {
+		return nil
+	}
+	return schema
+}
+
+// The swagger type a Go type is parsed from, empty if it could be anything
+func schemaType(goType reflect.Type) string {
+	if goType == reflect.TypeFor[time.Time]() {
+		return "string"
+	}
+
+	switch goType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct:
+		return "object"
+	}
+	return ""
+}
+
+// The name a field is parsed from, the same way encoding/json picks it
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// Like encoding/json, an exact match is preferred but property names are otherwise case-insensitive
+func findProperty(properties map[string]*swaggerSchema, name string) *swaggerSchema {
+	if property, pres := properties[name]; pres {
+		return property
+	}
+
+	for key, property := range properties {
+		if strings.EqualFold(key, name) {
+			return property
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package ESI
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSwagger = `{
+	"info": {"version": "1.36"},
+	"paths": {
+		"/incursions/": {"get": {"deprecated": true, "responses": {"200": {"schema": {"type": "array", "items": {
+			"type": "object", "properties": {
+				"constellation_id": {"type": "integer"},
+				"infested_solar_systems": {"type": "array", "items": {"type": "integer"}},
+				"influence": {"type": "number"},
+				"staging_solar_system_id": {"type": "integer"},
+				"state": {"type": "string"}
+			}}}}}}},
+		"/universe/systems/{system_id}/": {"parameters": [], "get": {"responses": {"200": {"schema": {"$ref": "#/definitions/system"}}}}},
+		"/universe/stargates/{stargate_id}/": {"get": {"responses": {"200": {"schema": {"type": "object", "properties": {
+			"destination": {"type": "object", "properties": {"stargate_id": {"type": "integer"}, "system_id": {"type": "string"}}},
+			"name": {"type": "string"},
+			"stargate_id": {"type": "integer"},
+			"system_id": {"type": "integer"}
+		}}}}}},
+		"/sovereignty/map/": {"get": {"responses": {"200": {"schema": {"type": "array", "items": {"type": "object", "properties": {
+			"alliance_id": {"type": "integer"}, "corporation_id": {"type": "integer"}, "faction_id": {"type": "integer"}, "system_id": {"type": "integer"}
+		}}}}}}}
+	},
+	"definitions": {
+		"system": {"type": "object", "properties": {
+			"system_id": {"type": "integer"},
+			"name": {"type": "string"},
+			"security_status": {"type": "number", "format": "float"},
+			"constellation_id": {"type": "integer"},
+			"position": {"type": "object", "properties": {"x": {"type": "number"}, "y": {"type": "number"}, "z": {"type": "number"}}}
+		}}
+	}
+}`
+
+func TestCheckSwagger(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/swagger.json" {
+			w.Write([]byte(testSwagger))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL + "/latest"))
+	report, err := client.CheckSwagger(context.Background())
+	assert.NoError(err)
+	assert.Equal("1.36", report.SwaggerVersion)
+
+	// Fields that match, including system_ID matching system_id, aren't reported, so /sovereignty/map/ has no issues
+	assert.Equal([]ContractIssue{
+		{Endpoint: "GET /incursions/", Problem: "route deprecated"},
+		{Endpoint: "GET /alliances/{id}/", Problem: "route removed"},
+		{Endpoint: "POST /universe/names/", Problem: "route removed"},
+		{Endpoint: "GET /universe/regions/", Problem: "route removed"},
+		{Endpoint: "GET /universe/regions/{id}/", Problem: "route removed"},
+		{Endpoint: "GET /universe/constellations/{id}/", Problem: "route removed"},
+		{Endpoint: "GET /universe/systems/{id}/", Field: "stargates", Problem: "field removed"},
+		{Endpoint: "GET /route/{id}/{id}/", Problem: "route removed"},
+		{Endpoint: "GET /universe/stargates/{id}/", Field: "destination.system_id", Problem: "type changed from integer to string"},
+		{Endpoint: "GET /status/", Problem: "route removed"},
+	}, report.Issues)
+
+	assert.Equal(report.Issues, client.Health().Contract.Issues)
+
+	t.Run("Requests match the contracts", func(t *testing.T) {
+		var mut sync.Mutex
+		requested := make(map[string]bool)
+		var client ESIClient
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mut.Lock()
+			requested[client.endpointName(r)] = true
+			mut.Unlock()
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		// Every response fails to parse as anything but a list, only the requests matter
+		client = NewClient(WithBaseURL(server.URL))
+		ctx := context.Background()
+		client.GetIncursions(ctx)
+		client.GetAllianceData(ctx, 99000001)
+		client.GetNames(ctx, []int{10000060})
+		client.GetRegions(ctx)
+		client.GetRegionInfo(ctx, 10000060)
+		client.GetConstInfo(ctx, 20000001)
+		client.GetSystemInfo(ctx, 30000001)
+		client.GetRouteLength(ctx, 30000001, 30000002)
+		client.GetStargateData(ctx, 50000001)
+		client.GetSovMap(ctx)
+		client.CheckHealth(ctx)
+
+		routes := make(map[string]bool)
+		for _, contract := range contracts {
+			endpoint := metaEndpointName(metaRouteStatus{Method: contract.method, Route: contract.route})
+			routes[endpoint] = true
+			assert.True(requested[endpoint], "%s is never requested", endpoint)
+		}
+		for endpoint := range requested {
+			assert.True(routes[endpoint], "%s has no contract", endpoint)
+		}
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		client := NewClient(WithBaseURL(server.URL + "/other"))
+		report, err := client.CheckSwagger(context.Background())
+		assert.Error(err)
+		assert.NotEmpty(report.Error)
+		assert.NotEmpty(client.Health().Contract.Error)
+	})
+}
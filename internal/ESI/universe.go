@@ -2,11 +2,15 @@ package ESI
 
 import (
 	logging "IncursionBot/internal/Logging"
+	universe "IncursionBot/internal/Universe"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sort"
+	"time"
 )
 
 type NameResponse struct {
@@ -16,21 +20,25 @@ type NameResponse struct {
 }
 type NameMap map[int]string // Map of item IDs to names
 
-var cachedNames NameMap = make(NameMap)
+const nameLifetime time.Duration = time.Hour * 24 * 7 // Names of systems and regions never change, alliances rarely do
 
-func (c *ESIClient) GetNames(ids []int) (NameMap, error) {
-	var responseData []NameResponse
+func (c *ESIClient) GetNames(ctx context.Context, ids []int) (NameMap, error) {
 	result := make(NameMap)
 
 	// Filter out names that we already know
 	var unknownIDs []int
 	for _, id := range ids {
-		cacheEntry, pres := cachedNames[id]
+		if name, pres := c.universeName(id); pres {
+			result[id] = name
+			continue
+		}
+
+		cacheEntry, pres := cacheLoad[string](c.cache, nameKey(id), true)
 
-		if !pres {
+		if !pres || cacheEntry.Expired() {
 			unknownIDs = append(unknownIDs, id)
 		} else {
-			result[id] = cacheEntry
+			result[id] = cacheEntry.Data
 		}
 	}
 
@@ -39,44 +47,93 @@ func (c *ESIClient) GetNames(ids []int) (NameMap, error) {
 		return result, nil
 	}
 
-	// Find the remaining names
-	data, err := json.Marshal(unknownIDs)
+	// Find the remaining names, sharing the request with anyone looking up the same names right now
+	sort.Ints(unknownIDs)
+	names, err := c.flights.do(ctx, fmt.Sprint("POST /universe/names/ ", unknownIDs), func(ctx context.Context) (any, error) {
+		return c.fetchNames(ctx, unknownIDs)
+	})
 	if err != nil {
-		logging.Errorln("Failed to marshal IDs into json", err)
 		return result, err
 	}
+	responseData := names.([]NameResponse)
+
+	// Return result
+	expires := time.Now().Add(nameLifetime)
+	for _, nameData := range responseData {
+		cacheStore(c.cache, nameKey(nameData.ID), CacheEntry[string]{Data: nameData.Name, ExpirationTime: expires}, true)
+		result[nameData.ID] = nameData.Name
+	}
 
-	req, err := http.NewRequest("POST", c.baseURL+"/universe/names/", bytes.NewBuffer(data))
+	return result, nil
+}
+
+func (c *ESIClient) fetchNames(ctx context.Context, ids []int) ([]NameResponse, error) {
+	var responseData []NameResponse
+	data, err := json.Marshal(ids)
+	if err != nil {
+		logging.Errorln("Failed to marshal IDs into json", err)
+		return responseData, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/universe/names/", bytes.NewBuffer(data))
 	if err != nil {
 		logging.Errorln("Failed to create name request", req)
-		return result, err
+		return responseData, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.send(req) // Looking up names changes nothing, so the POST is safe to retry
 	if err != nil {
 		logging.Errorln("Failed HTTP request for names", err)
-		return result, err
+		return responseData, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(resp.Body)
 		logging.Errorf("Name endpoint returned a status code of %d: %s", resp.StatusCode, string(body))
-		return result, err
+		return responseData, fmt.Errorf("status code %d received from server", resp.StatusCode)
 	}
 
 	err = c.parseResults(resp, &responseData)
 	if err != nil {
 		logging.Errorln("Failed to parse name results", err)
-		return result, err
 	}
+	return responseData, err
+}
 
-	// Return result
-	for _, nameData := range responseData {
-		cachedNames[nameData.ID] = nameData.Name
-		result[nameData.ID] = nameData.Name
+// Names are cached per ID rather than per request so any later lookup can reuse them
+func nameKey(id int) string {
+	return fmt.Sprintf("name %d", id)
+}
+
+// ---------- REGION INFO ------------
+
+type RegionData struct {
+	ID             int    `json:"region_id"`
+	Name           string `json:"name"`
+	Constellations []int  `json:"constellations"`
+}
+
+// Gets the IDs of every region, including wormhole and abyssal space
+func (c *ESIClient) GetRegions(ctx context.Context) ([]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/universe/regions/", nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	entry, err := cachedCall[[]int](c, req, true)
+	return entry.Data, err
+}
+
+func (c *ESIClient) GetRegionInfo(ctx context.Context, regionID int) (RegionData, error) {
+	url := fmt.Sprintf("%s/universe/regions/%d/", c.baseURL, regionID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return RegionData{}, err
+	}
+
+	entry, err := cachedCall[RegionData](c, req, true)
+	return entry.Data, err
 }
 
 // ------- CONSTELLATION INFO --------
@@ -85,56 +142,70 @@ type ConstellationData struct {
 	ID       int    `json:"constellation_id"`
 	Name     string `json:"name"`
 	RegionID int    `json:"region_id"`
+	Systems  []int  `json:"systems"`
 }
 
-var constDataCache CacheMap = make(CacheMap)
+func (c *ESIClient) GetConstInfo(ctx context.Context, constID int) (ConstellationData, error) {
+	if constellation, pres := c.universeConstellation(constID); pres {
+		return constellation, nil
+	}
+
+	return c.fetchConstInfo(ctx, constID)
+}
 
-func (c *ESIClient) GetConstInfo(constID int) (ConstellationData, error) {
+func (c *ESIClient) fetchConstInfo(ctx context.Context, constID int) (ConstellationData, error) {
 	var response ConstellationData
 	url := fmt.Sprintf("%s/universe/constellations/%d/", c.baseURL, constID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		logging.Errorf("Failed to create constellation info request for id: %d", constID)
 		return response, err
 	}
 
-	cacheData := constDataCache[constID]
-	err = c.cachedCall(req, &cacheData, &response)
+	entry, err := cachedCall[ConstellationData](c, req, true)
 	if err != nil {
 		logging.Errorln("Error occurred in getting the constellation data", err)
-		return response, err
+		return entry.Data, err
 	}
 
-	return response, nil
+	return entry.Data, nil
 }
 
 // ----------- SYSTEM INFO -----------
 
 type SystemData struct {
-	ID            int           `json:"system_id"`
-	Name          string        `json:"name"`
-	SecStatus     float64       `json:"security_status"`
-	Gates         []int         `json:"stargates"`
-	SecurityClass SecurityClass // Not part of the response
+	ID              int               `json:"system_id"`
+	Name            string            `json:"name"`
+	SecStatus       float64           `json:"security_status"`
+	Gates           []int             `json:"stargates"`
+	ConstellationID int               `json:"constellation_id"`
+	Position        universe.Position `json:"position"`
+	SecurityClass   SecurityClass     `json:"-"` // Not part of the response
 }
 
-var systemCache CacheMap = make(CacheMap)
+func (c *ESIClient) GetSystemInfo(ctx context.Context, systemID int) (SystemData, error) {
+	if system, pres := c.universeSystem(systemID); pres {
+		return system, nil
+	}
 
-func (c *ESIClient) GetSystemInfo(systemID int) (SystemData, error) {
+	return c.fetchSystemInfo(ctx, systemID)
+}
+
+func (c *ESIClient) fetchSystemInfo(ctx context.Context, systemID int) (SystemData, error) {
 	var results SystemData
 	url := fmt.Sprintf("%s/universe/systems/%d/", c.baseURL, systemID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		logging.Errorln("An error occurred creating the system info request", err)
 		return results, err
 	}
 
-	cacheData := systemCache[systemID]
-	err = c.cachedCall(req, &cacheData, &results)
+	entry, err := cachedCall[SystemData](c, req, true)
 	if err != nil {
 		logging.Errorln("An error occurred getting system info", err)
 		return results, err
 	}
+	results = entry.Data
 	results.SecurityClass = guessSecClass(results.SecStatus)
 
 	return results, nil
@@ -143,22 +214,50 @@ func (c *ESIClient) GetSystemInfo(systemID int) (SystemData, error) {
 // TODO: Cache this endpoint
 type Route []int
 
-func (c *ESIClient) GetRouteLength(startSystem int, endSystem int) (int, error) {
-	var resultData Route
+// Gets the number of jumps between two systems, from the universe if there is one (see universe.Route for how that can
+// differ from ESI) and otherwise from ESI. Both give -1 for a route from a system to itself.
+func (c *ESIClient) GetRouteLength(ctx context.Context, startSystem int, endSystem int) (int, error) {
+	if route := c.universeRoute(startSystem, endSystem); route != nil {
+		return len(route) - 2, nil // Subtract off the start and end systems
+	}
+
 	url := fmt.Sprintf("%s/route/%d/%d/", c.baseURL, startSystem, endSystem)
-	resp, err := http.Get(url)
+	route, err := c.flights.do(ctx, "GET "+url, func(ctx context.Context) (any, error) {
+		return c.fetchRoute(ctx, url)
+	})
 	if err != nil {
-		logging.Errorln("Failed HTTP request for route length", err)
 		return -1, err
 	}
 
+	return len(route.(Route)) - 2, nil // Subtract off the start and end systems
+}
+
+func (c *ESIClient) fetchRoute(ctx context.Context, url string) (Route, error) {
+	var resultData Route
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		logging.Errorln("Failed to create route request", err)
+		return resultData, err
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		logging.Errorln("Failed HTTP request for route length", err)
+		return resultData, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		logging.Errorf("Route endpoint returned a status code of %d: %s", resp.StatusCode, string(body))
+		return resultData, fmt.Errorf("status code %d received from server", resp.StatusCode)
+	}
+
 	err = c.parseResults(resp, &resultData)
 	if err != nil {
 		logging.Errorln("Error occurred parsing results", err)
-		return -1, err
 	}
-
-	return len(resultData) - 2, nil // Subtract off the start and end systems
+	return resultData, err
 }
 
 type SecurityClass string
@@ -183,27 +282,29 @@ type StargateResponse struct {
 		GateID   int `json:"stargate_id"`
 		SystemID int `json:"system_id"`
 	} `json:"destination"`
-	Name   string `json:"name"`
-	GateID int    `json:"stargate_id"`
+	Name     string `json:"name"`
+	GateID   int    `json:"stargate_id"`
+	SystemID int    `json:"system_id"` // System the gate is in
 }
 
-var gateCache = make(CacheMap)
-
-func (c *ESIClient) GetStargateData(gateID int) (StargateResponse, error) {
+func (c *ESIClient) GetStargateData(ctx context.Context, gateID int) (StargateResponse, error) {
 	var resultData StargateResponse
 	url := fmt.Sprintf("%s/universe/stargates/%d/", c.baseURL, gateID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return resultData, err
 	}
 
-	cacheData := gateCache[gateID]
-	err = c.cachedCall(req, &cacheData, &resultData)
-	return resultData, err
+	entry, err := cachedCall[StargateResponse](c, req, true)
+	return entry.Data, err
 }
 
-func (c *ESIClient) GetSystemConnections(systemID int) ([]StargateResponse, error) {
-	systemData, err := c.GetSystemInfo(systemID)
+func (c *ESIClient) GetSystemConnections(ctx context.Context, systemID int) ([]StargateResponse, error) {
+	if gates, pres := c.universeStargates(systemID); pres {
+		return gates, nil
+	}
+
+	systemData, err := c.GetSystemInfo(ctx, systemID)
 	var result []StargateResponse
 
 	if err != nil {
@@ -213,7 +314,7 @@ func (c *ESIClient) GetSystemConnections(systemID int) ([]StargateResponse, erro
 
 	for _, gate := range systemData.Gates {
 		go func(result chan<- StargateResponse, gateID int) {
-			res, err := c.GetStargateData(gateID)
+			res, err := c.GetStargateData(ctx, gateID)
 			if err != nil {
 				resultChan <- StargateResponse{}
 				fmt.Println(err)
@@ -238,16 +339,14 @@ type SovResponse struct {
 	System      int `json:"system_ID"`
 }
 
-var sovCache CacheEntry
-
-func (c *ESIClient) GetSovMap() ([]SovResponse, error) {
+func (c *ESIClient) GetSovMap(ctx context.Context) ([]SovResponse, error) {
 	var response []SovResponse
-	url := fmt.Sprintf("%s/sovereignty/map", c.baseURL)
-	req, err := http.NewRequest("GET", url, nil)
+	url := fmt.Sprintf("%s/sovereignty/map/", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return response, err
 	}
 
-	err = c.cachedCall(req, &sovCache, &response)
-	return response, err
+	entry, err := cachedCall[[]SovResponse](c, req, false)
+	return entry.Data, err
 }
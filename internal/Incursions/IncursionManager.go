@@ -3,21 +3,28 @@ package incursions
 import (
 	"IncursionBot/internal/ESI"
 	logging "IncursionBot/internal/Logging"
-	"fmt"
+	"context"
 	"sync"
 	"time"
 )
 
 type NotifFunction func(Incursion)
+type ListNotifFunction func(IncursionList)
+type ThresholdNotifFunction func(incursion Incursion, threshold float64)
 
 type IncursionManager struct {
 	incursionMut            sync.Mutex
 	incursions              IncursionList
 	nullTracker, lowTracker SpawnTracker
 
+	Reminders          *Reminders // Optional, schedules reminders as incursions move through their lifecycle
 	OnNewIncursion     NotifFunction
 	OnIncursionUpdate  NotifFunction
 	OnIncursionDespawn NotifFunction
+	OnListChanged      ListNotifFunction // Optional, called with the full list after every update
+
+	InfluenceThresholds  []float64              // Influence levels from 0 to 1 that trigger OnInfluenceThreshold when crossed
+	OnInfluenceThreshold ThresholdNotifFunction // Optional, called when an incursion's influence crosses a threshold
 }
 
 func (manager *IncursionManager) GetIncursions() IncursionList {
@@ -26,13 +33,33 @@ func (manager *IncursionManager) GetIncursions() IncursionList {
 	return manager.incursions
 }
 
-func (manager *IncursionManager) NextSpawns() string {
-	return fmt.Sprintf("\nNext nullsec spawn window: %s\nNext lowsec spawn window: %s",
-		manager.nullTracker.nextRespawn(),
-		manager.lowTracker.nextRespawn())
+// Predicts the next nullsec and lowsec spawn windows
+func (manager *IncursionManager) NextSpawns() []SpawnPrediction {
+	return []SpawnPrediction{manager.NextSpawn(NullSec), manager.NextSpawn(LowSec)}
+}
+
+// Predicts the next spawn window for the given security class
+func (manager *IncursionManager) NextSpawn(security SecurityClass) SpawnPrediction {
+	tracker := &manager.lowTracker
+	if security == NullSec {
+		tracker = &manager.nullTracker
+	}
+
+	prediction := tracker.nextRespawn(time.Now())
+	prediction.Security = security
+	return prediction
+}
+
+// Gets the upcoming respawn windows for the given security class, soonest first
+func (manager *IncursionManager) RespawnWindows(security SecurityClass) []RespawnWindow {
+	if security == NullSec {
+		return manager.nullTracker.respawnWindows()
+	}
+
+	return manager.lowTracker.respawnWindows()
 }
 
-func (manager *IncursionManager) PopulateIncursions(initialList IncursionList, client *ESI.ESIClient) {
+func (manager *IncursionManager) PopulateIncursions(ctx context.Context, initialList IncursionList, client *ESI.ESIClient) {
 	var toSave IncursionList
 
 	for _, incursion := range initialList {
@@ -40,7 +67,7 @@ func (manager *IncursionManager) PopulateIncursions(initialList IncursionList, c
 			continue
 		}
 
-		incursion.Layout = GenerateIncursionLayout(&incursion, client)
+		incursion.Layout = GenerateIncursionLayout(ctx, &incursion, client)
 		logging.Infof("Found initial incursion in %s", incursion.ToString())
 		toSave = append(toSave, incursion)
 	}
@@ -48,18 +75,27 @@ func (manager *IncursionManager) PopulateIncursions(initialList IncursionList, c
 	manager.incursionMut.Lock()
 	manager.incursions = toSave
 	manager.incursionMut.Unlock()
+
+	if manager.OnListChanged != nil {
+		manager.OnListChanged(toSave)
+	}
 }
 
-func (manager *IncursionManager) ProcessIncursions(newIncursions IncursionList, client *ESI.ESIClient) {
+func (manager *IncursionManager) ProcessIncursions(ctx context.Context, newIncursions IncursionList, client *ESI.ESIClient) {
 	var toSave IncursionList
 	logging.Infoln("------Processing new set of incursions-----")
 
+	// Work on a copy so readers never see an incursion half updated
+	manager.incursionMut.Lock()
+	current := append(IncursionList{}, manager.incursions...)
+	manager.incursionMut.Unlock()
+
 	for _, incursion := range newIncursions {
 		if incursion.Security == HighSec {
 			continue // We do not give a fuck about high sec
 		}
 
-		existingIncursion := manager.incursions.Find(incursion)
+		existingIncursion := current.Find(incursion)
 
 		if existingIncursion == nil {
 			if !incursion.IsValid {
@@ -74,7 +110,8 @@ func (manager *IncursionManager) ProcessIncursions(newIncursions IncursionList,
 				manager.lowTracker.Spawn(incursion)
 			}
 
-			incursion.Layout = GenerateIncursionLayout(&incursion, client)
+			incursion.Layout = GenerateIncursionLayout(ctx, &incursion, client)
+			manager.Reminders.Spawn(incursion)
 			manager.OnNewIncursion(incursion)
 			toSave = append(toSave, incursion)
 		} else {
@@ -82,10 +119,18 @@ func (manager *IncursionManager) ProcessIncursions(newIncursions IncursionList,
 
 			// Attempt to regenerate the spawn layout if generation was interrupted by ESI/networking previously
 			if !existingIncursion.Layout.IsComplete() {
-				incursion.Layout = GenerateIncursionLayout(existingIncursion, client)
+				incursion.Layout = GenerateIncursionLayout(ctx, existingIncursion, client)
 			}
 
-			if existingIncursion.Update(incursion.Influence, incursion.State) {
+			previousInfluence := existingIncursion.Influence
+			stateChanged := existingIncursion.Update(incursion.Influence, incursion.State)
+
+			threshold, crossed := CrossedThreshold(manager.InfluenceThresholds, previousInfluence, existingIncursion.Influence)
+			if crossed && manager.OnInfluenceThreshold != nil {
+				manager.OnInfluenceThreshold(*existingIncursion, threshold)
+			}
+
+			if stateChanged {
 				existingIncursion.StateChanged = time.Now()
 
 				if incursion.Security == NullSec {
@@ -94,6 +139,7 @@ func (manager *IncursionManager) ProcessIncursions(newIncursions IncursionList,
 					manager.lowTracker.Update(*existingIncursion)
 				}
 
+				manager.Reminders.Update(*existingIncursion)
 				manager.OnIncursionUpdate(*existingIncursion)
 			}
 
@@ -102,7 +148,7 @@ func (manager *IncursionManager) ProcessIncursions(newIncursions IncursionList,
 	}
 
 	// Check for despawns
-	for _, existingIncursion := range manager.incursions {
+	for _, existingIncursion := range current {
 		if newIncursions.Find(existingIncursion) == nil {
 			logging.Infof("Incursion in %s despawned", existingIncursion.ToString())
 			if existingIncursion.Security == NullSec {
@@ -111,6 +157,7 @@ func (manager *IncursionManager) ProcessIncursions(newIncursions IncursionList,
 				manager.lowTracker.Despawn(existingIncursion)
 			}
 
+			manager.Reminders.Despawn(existingIncursion)
 			manager.OnIncursionDespawn(existingIncursion)
 		}
 	}
@@ -118,4 +165,8 @@ func (manager *IncursionManager) ProcessIncursions(newIncursions IncursionList,
 	manager.incursionMut.Lock()
 	manager.incursions = toSave
 	manager.incursionMut.Unlock()
+
+	if manager.OnListChanged != nil {
+		manager.OnListChanged(toSave)
+	}
 }
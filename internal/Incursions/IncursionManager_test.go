@@ -0,0 +1,75 @@
+package incursions
+
+import (
+	"IncursionBot/internal/ESI"
+	logging "IncursionBot/internal/Logging"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Polls keep updating the manager while the web and chat handlers read predictions from it, run with -race
+func TestConcurrentPolling(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	server := httptest.NewServer(http.NotFoundHandler()) // Layouts can't be worked out, which doesn't matter here
+	defer server.Close()
+	client := ESI.NewClient(ESI.WithBaseURL(server.URL))
+
+	manager := IncursionManager{
+		OnNewIncursion:     func(Incursion) {},
+		OnIncursionUpdate:  func(Incursion) {},
+		OnIncursionDespawn: func(Incursion) {},
+	}
+
+	incursion := func(id int, security SecurityClass, state IncursionState) Incursion {
+		return Incursion{Layout: IncursionLayout{StagingSystem: NamedItem{ID: id}}, Security: security, State: state, IsValid: true}
+	}
+	polls := []IncursionList{
+		{incursion(1, NullSec, Established), incursion(2, LowSec, Established)},
+		{incursion(1, NullSec, Mobilizing), incursion(2, LowSec, Withdrawing)},
+		{incursion(1, NullSec, Withdrawing)},
+		{},
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			// What the calendar feed, web status and summaries read
+			for _, prediction := range manager.NextSpawns() {
+				_ = prediction.BasedOn.State
+			}
+			for _, window := range manager.RespawnWindows(NullSec) {
+				_ = window.Incursion.StateChanged
+			}
+			for _, incursion := range manager.GetIncursions() {
+				_ = incursion.State
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		for _, poll := range polls {
+			manager.ProcessIncursions(context.Background(), poll, &client)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	assert.Empty(manager.GetIncursions())
+	assert.NotEmpty(manager.RespawnWindows(NullSec))
+}
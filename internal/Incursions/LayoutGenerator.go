@@ -4,6 +4,7 @@ import (
 	"IncursionBot/internal/ESI"
 	logging "IncursionBot/internal/Logging"
 	"IncursionBot/internal/Utils"
+	"context"
 )
 
 func calculateLayoutAmounts(numberOfSystems int) (assaults int, vanguards int) {
@@ -23,7 +24,7 @@ func calculateLayoutAmounts(numberOfSystems int) (assaults int, vanguards int) {
 	return
 }
 
-func GenerateIncursionLayout(incursion *Incursion, esi *ESI.ESIClient) (layout IncursionLayout) {
+func GenerateIncursionLayout(ctx context.Context, incursion *Incursion, esi *ESI.ESIClient) (layout IncursionLayout) {
 	assaults, vanguards := calculateLayoutAmounts(len(incursion.Systems))
 
 	stagingID := incursion.Layout.StagingSystem.ID
@@ -32,7 +33,7 @@ func GenerateIncursionLayout(incursion *Incursion, esi *ESI.ESIClient) (layout I
 	layout.StagingSystem = incursion.Layout.StagingSystem // Preserve previously known staging
 	layout.HQSystem.Name = "Unknown"                      // Default HQ name
 
-	connections, _ := esi.GetSystemConnections(stagingID)
+	connections, _ := esi.GetSystemConnections(ctx, stagingID)
 	data := HQGuessData{
 		remainingAssaults:  assaults,
 		remainingVanguards: vanguards,
@@ -47,7 +48,7 @@ func GenerateIncursionLayout(incursion *Incursion, esi *ESI.ESIClient) (layout I
 		}
 	}
 
-	traverseSystems(&data, incursion.Systems, esi)
+	traverseSystems(ctx, &data, incursion.Systems, esi)
 	return
 }
 
@@ -71,14 +72,14 @@ func (list *TestList) ReverseSort() {
 	*list = result
 }
 
-func traverseSystems(data *HQGuessData, validSystems Utils.IDList, esi *ESI.ESIClient) {
+func traverseSystems(ctx context.Context, data *HQGuessData, validSystems Utils.IDList, esi *ESI.ESIClient) {
 	if data.queue.IsEmpty() {
 		logging.Errorln("Queue was empty, ESI issue may have occurred")
 		return
 	}
 
 	currentSystem := data.queue.Pop()
-	systemInfo, err := esi.GetSystemInfo(currentSystem.SystemID)
+	systemInfo, err := esi.GetSystemInfo(ctx, currentSystem.SystemID)
 
 	if err != nil {
 		logging.Errorln("Couldn't get system info, stopping guess", err)
@@ -93,7 +94,7 @@ func traverseSystems(data *HQGuessData, validSystems Utils.IDList, esi *ESI.ESIC
 	} // Found our boy
 
 	var connectingSystems TestList
-	connectingSystems, err = esi.GetSystemConnections(currentSystem.SystemID)
+	connectingSystems, err = esi.GetSystemConnections(ctx, currentSystem.SystemID)
 
 	if err != nil {
 		logging.Errorf("Couldn't get the system connections to %d, stopping guessing: %v",
@@ -122,5 +123,5 @@ func traverseSystems(data *HQGuessData, validSystems Utils.IDList, esi *ESI.ESIC
 		}
 	}
 
-	traverseSystems(data, validSystems, esi)
+	traverseSystems(ctx, data, validSystems, esi)
 }
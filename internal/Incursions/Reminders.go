@@ -0,0 +1,154 @@
+package incursions
+
+import (
+	logging "IncursionBot/internal/Logging"
+	scheduler "IncursionBot/internal/Scheduler"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// How far ahead of each lifecycle change reminders are sent
+const (
+	mobilizingWarning    time.Duration = time.Hour
+	withdrawingWarning   time.Duration = time.Minute * 30
+	respawnClosesWarning time.Duration = time.Hour * 2
+)
+
+type ReminderKind string
+
+const (
+	MobilizingEnding     ReminderKind = "mobilizingEnding"     // Mobilizing incursion is about to start withdrawing
+	DespawnSoon          ReminderKind = "despawnSoon"          // Withdrawing incursion is about to despawn
+	RespawnWindowOpen    ReminderKind = "respawnWindowOpen"    // A respawn window just opened
+	RespawnWindowClosing ReminderKind = "respawnWindowClosing" // A respawn window is about to close
+)
+
+// A reminder that something is about to happen to an incursion or its respawn window
+type Reminder struct {
+	Kind      ReminderKind
+	Incursion Incursion     // Incursion the reminder is about, for respawn windows this is the one that despawned
+	At        time.Time     // Time the reminded event happens
+	Lead      time.Duration // How far ahead of the event the reminder was sent
+}
+
+type ReminderFunction func(Reminder)
+
+// Schedules reminders from the incursion lifecycle, rescheduling them as incursions change state
+type Reminders struct {
+	Scheduler  *scheduler.Scheduler
+	OnReminder ReminderFunction
+
+	mut            sync.Mutex
+	lifecycleJobs  map[int][]scheduler.JobID             // Staging system to pending lifecycle reminders
+	respawningJobs map[SecurityClass][][]scheduler.JobID // Pending respawn window reminders, oldest first
+}
+
+// Schedules lifecycle reminders for a new incursion and consumes the oldest respawn window of its security class
+func (r *Reminders) Spawn(incursion Incursion) {
+	if r == nil {
+		return
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if pending := r.respawningJobs[incursion.Security]; len(pending) > 0 {
+		r.cancel(pending[0])
+		r.respawningJobs[incursion.Security] = pending[1:]
+	}
+
+	r.scheduleLifecycle(incursion)
+}
+
+// Reschedules lifecycle reminders for an incursion that changed state
+func (r *Reminders) Update(incursion Incursion) {
+	if r == nil {
+		return
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.scheduleLifecycle(incursion)
+}
+
+// Cancels the reminders for a despawned incursion and schedules reminders for the respawn window it opens
+func (r *Reminders) Despawn(incursion Incursion) {
+	if r == nil {
+		return
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.cancel(r.lifecycleJobs[incursion.Layout.StagingSystem.ID])
+	delete(r.lifecycleJobs, incursion.Layout.StagingSystem.ID)
+
+	respawning := incursion
+	respawning.State = Respawning
+	respawning.StateChanged = time.Now()
+
+	windowStart := respawnTime(respawning)
+	windowEnd := respawning.StateChanged.Add(respawnWindowEnd)
+
+	var jobs []scheduler.JobID
+	jobs = append(jobs, r.schedule(RespawnWindowOpen, incursion, windowStart, 0)...)
+	jobs = append(jobs, r.schedule(RespawnWindowClosing, incursion, windowEnd, respawnClosesWarning)...)
+
+	if r.respawningJobs == nil {
+		r.respawningJobs = make(map[SecurityClass][][]scheduler.JobID)
+	}
+	r.respawningJobs[incursion.Security] = append(r.respawningJobs[incursion.Security], jobs)
+}
+
+// Replaces any pending lifecycle reminders for the incursion with ones for its current state. Must be called with mut locked.
+func (r *Reminders) scheduleLifecycle(incursion Incursion) {
+	stagingID := incursion.Layout.StagingSystem.ID
+	r.cancel(r.lifecycleJobs[stagingID])
+	delete(r.lifecycleJobs, stagingID)
+
+	if incursion.StateChanged.IsZero() {
+		return // Don't know when the state started, so can't guess when it ends
+	}
+
+	var kind ReminderKind
+	var lead time.Duration
+	switch incursion.State {
+	case Mobilizing:
+		kind, lead = MobilizingEnding, mobilizingWarning
+	case Withdrawing:
+		kind, lead = DespawnSoon, withdrawingWarning
+	default:
+		return // Established incursions can end at any time
+	}
+
+	stateEnd, err := incursion.TimeLeftInSpawn()
+	if err != nil {
+		logging.Errorln("Error getting time left in spawn", err)
+		return
+	}
+
+	if r.lifecycleJobs == nil {
+		r.lifecycleJobs = make(map[int][]scheduler.JobID)
+	}
+	r.lifecycleJobs[stagingID] = r.schedule(kind, incursion, stateEnd, lead)
+}
+
+// Schedules a single reminder, skipping it if it would already be in the past
+func (r *Reminders) schedule(kind ReminderKind, incursion Incursion, at time.Time, lead time.Duration) []scheduler.JobID {
+	sendTime := at.Add(-lead)
+	if !sendTime.After(time.Now()) {
+		return nil
+	}
+
+	reminder := Reminder{Kind: kind, Incursion: incursion, At: at, Lead: lead}
+	name := fmt.Sprintf("%s reminder for %s", kind, incursion.Layout.StagingSystem.Name)
+	return []scheduler.JobID{r.Scheduler.At(name, sendTime, func() { r.OnReminder(reminder) })}
+}
+
+func (r *Reminders) cancel(jobs []scheduler.JobID) {
+	for _, job := range jobs {
+		r.Scheduler.Cancel(job)
+	}
+}
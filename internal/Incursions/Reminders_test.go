@@ -0,0 +1,96 @@
+package incursions
+
+import (
+	logging "IncursionBot/internal/Logging"
+	scheduler "IncursionBot/internal/Scheduler"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReminders(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	var sched scheduler.Scheduler
+	testSubject := Reminders{Scheduler: &sched, OnReminder: func(Reminder) {}}
+
+	testInc := Incursion{
+		Layout:       IncursionLayout{StagingSystem: NamedItem{ID: 1}},
+		Security:     NullSec,
+		State:        Mobilizing,
+		StateChanged: time.Now(),
+	}
+
+	nextRun := func(job scheduler.JobID) time.Time {
+		next, scheduled := sched.NextRun(job)
+		assert.True(scheduled)
+		return next
+	}
+
+	t.Run("Spawn", func(t *testing.T) {
+		testSubject.Spawn(testInc)
+
+		jobs := testSubject.lifecycleJobs[1]
+		assert.Len(jobs, 1)
+		assert.Equal(testInc.StateChanged.Add(mobilizingLifetime-mobilizingWarning), nextRun(jobs[0]))
+	})
+
+	t.Run("State change reschedules", func(t *testing.T) {
+		oldJobs := testSubject.lifecycleJobs[1]
+		testInc.State = Withdrawing
+		testSubject.Update(testInc)
+
+		_, scheduled := sched.NextRun(oldJobs[0])
+		assert.False(scheduled)
+
+		jobs := testSubject.lifecycleJobs[1]
+		assert.Len(jobs, 1)
+		assert.Equal(testInc.StateChanged.Add(withdrawingLifetime-withdrawingWarning), nextRun(jobs[0]))
+	})
+
+	t.Run("Established has no reminder", func(t *testing.T) {
+		testInc.State = Established
+		testSubject.Update(testInc)
+		assert.Empty(testSubject.lifecycleJobs[1])
+		testInc.State = Withdrawing
+		testSubject.Update(testInc)
+	})
+
+	t.Run("Despawn cancels and schedules respawn window", func(t *testing.T) {
+		oldJobs := testSubject.lifecycleJobs[1]
+		before := time.Now()
+		testSubject.Despawn(testInc)
+
+		_, scheduled := sched.NextRun(oldJobs[0])
+		assert.False(scheduled)
+		assert.Empty(testSubject.lifecycleJobs[1])
+
+		windows := testSubject.respawningJobs[NullSec]
+		assert.Len(windows, 1)
+		assert.Len(windows[0], 2)
+		assert.WithinDuration(before.Add(respawnWindowStart), nextRun(windows[0][0]), time.Second)
+		assert.WithinDuration(before.Add(respawnWindowEnd-respawnClosesWarning), nextRun(windows[0][1]), time.Second)
+	})
+
+	t.Run("Spawn consumes respawn window", func(t *testing.T) {
+		windows := testSubject.respawningJobs[NullSec]
+		newInc := Incursion{
+			Layout:   IncursionLayout{StagingSystem: NamedItem{ID: 2}},
+			Security: NullSec,
+		}
+		testSubject.Spawn(newInc)
+
+		_, scheduled := sched.NextRun(windows[0][0])
+		assert.False(scheduled)
+		assert.Empty(testSubject.respawningJobs[NullSec])
+		assert.Empty(testSubject.lifecycleJobs[2])
+	})
+
+	t.Run("Nil reminders are ignored", func(t *testing.T) {
+		var nilReminders *Reminders
+		nilReminders.Spawn(testInc)
+		nilReminders.Update(testInc)
+		nilReminders.Despawn(testInc)
+	})
+}
@@ -2,16 +2,44 @@ package incursions
 
 import (
 	logging "IncursionBot/internal/Logging"
-	"fmt"
+	"sort"
+	"sync"
 	"time"
 )
 
 const respawnWindowStart time.Duration = time.Hour * 12
 const respawnWindowEnd time.Duration = time.Hour * 36
-const day time.Duration = time.Hour * 24
-const unknownString string = "Unknown"
 
+// Period in which a replacement for an incursion can spawn, starting 12 hours after it despawns and lasting a day
+type RespawnWindow struct {
+	Incursion  Incursion // Incursion whose despawn opens the window
+	Start      time.Time
+	End        time.Time
+	UpperBound bool // The incursion is established, so the window will open no later than Start
+}
+
+// How certain a spawn prediction is
+type PredictionKind string
+
+const (
+	UnknownPrediction    PredictionKind = "unknown"    // No incursion to base a prediction on
+	ExactPrediction      PredictionKind = "exact"      // The window opens at Earliest
+	UpperBoundPrediction PredictionKind = "upperBound" // Based on an established incursion, so the window opens no later than Earliest
+	InWindowPrediction   PredictionKind = "inWindow"   // The window is open now and closes at Latest
+)
+
+// Prediction of the next respawn window for a security class
+type SpawnPrediction struct {
+	Security SecurityClass
+	Kind     PredictionKind
+	Earliest time.Time // When the window opens, unset for unknown predictions
+	Latest   time.Time // When the window closes, unset for unknown predictions
+	BasedOn  Incursion // Incursion whose despawn opens the window
+}
+
+// Tracks the incursions of one security class to predict their respawns. Safe for concurrent use.
 type SpawnTracker struct {
+	mut                  sync.Mutex
 	currentIncursions    IncursionList
 	respawningIncursions IncursionList
 }
@@ -38,23 +66,10 @@ func respawnTime(incursion Incursion) time.Time {
 	return time.Time{}
 }
 
-// The default toString only shows up to hours, we'd like to show days
-func formatDuration(duration time.Duration) string {
-	var result string
-
-	if duration > day {
-		result += fmt.Sprintf("%dd", int(duration.Hours()/24))
-		duration = duration % day
-	}
-
-	result += fmt.Sprintf("%dh", int(duration.Hours()))
-	duration = duration % time.Hour
-
-	result += fmt.Sprintf("%dm", int(duration.Minutes()))
-	return result
-}
-
 func (tracker *SpawnTracker) Despawn(incursion Incursion) {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
 	tracker.currentIncursions.RemoveFunc(incursion.Equal)
 
 	incursion.State = Respawning
@@ -64,6 +79,9 @@ func (tracker *SpawnTracker) Despawn(incursion Incursion) {
 }
 
 func (tracker *SpawnTracker) Spawn(incursion Incursion) {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
 	tracker.currentIncursions = append(tracker.currentIncursions, incursion)
 
 	if !tracker.respawningIncursions.Empty() {
@@ -82,6 +100,9 @@ func (tracker *SpawnTracker) Spawn(incursion Incursion) {
 }
 
 func (tracker *SpawnTracker) Update(incursion Incursion) {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
 	found := tracker.currentIncursions.Find(incursion)
 
 	if found != nil {
@@ -97,37 +118,55 @@ func (tracker *SpawnTracker) Update(incursion Incursion) {
 	}
 }
 
-func (tracker *SpawnTracker) nextRespawn() string {
-	var nextToRespawn Incursion
-	var nextRespawnTime time.Time
+// Predicts the next respawn window from the tracked incursions. Windows that have already closed are ignored.
+func (tracker *SpawnTracker) nextRespawn(now time.Time) SpawnPrediction {
+	for _, window := range tracker.respawnWindows() {
+		if window.End.Before(now) {
+			continue
+		}
 
-	toCheck := append(tracker.currentIncursions, tracker.respawningIncursions...)
-	for _, incursion := range toCheck {
-		logging.Debugf("Considering %s", incursion.Layout.StagingSystem.Name)
-		logging.Debugf("State: %s", incursion.State)
-		respawnTime := respawnTime(incursion)
-		if !respawnTime.IsZero() && (respawnTime.Before(nextRespawnTime) || nextRespawnTime.IsZero()) {
-			logging.Debugf("%s now the next to respawn", incursion.Layout.StagingSystem.Name)
-			nextToRespawn = incursion
-			nextRespawnTime = respawnTime
+		prediction := SpawnPrediction{
+			Kind:     ExactPrediction,
+			Earliest: window.Start,
+			Latest:   window.End,
+			BasedOn:  window.Incursion,
 		}
-	}
 
-	if nextRespawnTime.IsZero() {
-		return unknownString
+		if window.UpperBound {
+			prediction.Kind = UpperBoundPrediction
+		} else if !window.Start.After(now) {
+			prediction.Kind = InWindowPrediction
+		}
+
+		logging.Infof("Picked %s as next to respawn, respawn time %s", window.Incursion.Layout.StagingSystem.Name, window.Start)
+		return prediction
 	}
 
-	logging.Infof("Picked %s as next to respawn, respawn time %s", nextToRespawn.Layout.StagingSystem.Name, nextRespawnTime)
-	switch nextToRespawn.State {
-	case Established:
-		return fmt.Sprintf("No more than %s", formatDuration(time.Until(nextRespawnTime)))
-	case Respawning:
-		if time.Now().After(nextRespawnTime) {
-			endOfSpawn := nextToRespawn.StateChanged.Add(respawnWindowEnd)
-			return fmt.Sprintf("Currently in a spawn window for another %s", formatDuration(time.Until(endOfSpawn)))
+	return SpawnPrediction{Kind: UnknownPrediction}
+}
+
+// Gets the respawn window of every tracked incursion whose despawn time can be worked out, soonest first
+func (tracker *SpawnTracker) respawnWindows() []RespawnWindow {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
+	var windows []RespawnWindow
+
+	toCheck := append(append(IncursionList{}, tracker.currentIncursions...), tracker.respawningIncursions...)
+	for _, incursion := range toCheck {
+		start := respawnTime(incursion)
+		if start.IsZero() {
+			continue
 		}
-		fallthrough // Not yet in a spawn window, return the normal string format
-	default:
-		return formatDuration(time.Until(nextRespawnTime))
+
+		windows = append(windows, RespawnWindow{
+			Incursion:  incursion,
+			Start:      start,
+			End:        start.Add(respawnWindowEnd - respawnWindowStart),
+			UpperBound: incursion.State == Established,
+		})
 	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+	return windows
 }
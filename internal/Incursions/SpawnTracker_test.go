@@ -57,19 +57,21 @@ func TestNextRespawn(t *testing.T) {
 	logging.InitLogger(true)
 	var testSubject SpawnTracker
 	testTime := time.Now()
-	timeOne := formatDuration(36*time.Hour - time.Second)
-	time.Sleep(200)
 
 	t.Run("1 incursion, no info", func(t *testing.T) {
 		testSubject.currentIncursions = append(testSubject.currentIncursions, Incursion{})
-		assert.Equal(unknownString, testSubject.nextRespawn())
+		assert.Equal(UnknownPrediction, testSubject.nextRespawn(testTime).Kind)
 	})
 
 	t.Run("1 incursion, valid info", func(t *testing.T) {
 		testSubject.currentIncursions[0].State = Withdrawing
 		testSubject.currentIncursions[0].StateChanged = testTime
 
-		assert.Equal(timeOne, testSubject.nextRespawn())
+		prediction := testSubject.nextRespawn(testTime)
+		assert.Equal(ExactPrediction, prediction.Kind)
+		assert.Equal(testTime.Add(36*time.Hour), prediction.Earliest)
+		assert.Equal(testTime.Add(60*time.Hour), prediction.Latest)
+		assert.Equal(Withdrawing, prediction.BasedOn.State)
 	})
 
 	t.Run("3 incursions, some valid", func(t *testing.T) {
@@ -82,12 +84,60 @@ func TestNextRespawn(t *testing.T) {
 			State: Withdrawing,
 		})
 
-		assert.Equal(timeOne, testSubject.nextRespawn())
+		prediction := testSubject.nextRespawn(testTime)
+		assert.Equal(ExactPrediction, prediction.Kind)
+		assert.Equal(testTime.Add(36*time.Hour), prediction.Earliest)
 	})
 
-	t.Run("3 incursions, all valid", func(t *testing.T) {})
+	t.Run("Established is an upper bound", func(t *testing.T) {
+		testSubject := SpawnTracker{currentIncursions: IncursionList{{State: Established, StateChanged: testTime}}}
 
-	t.Run("3 incursions, 2 respawning", func(t *testing.T) {})
+		prediction := testSubject.nextRespawn(testTime)
+		assert.Equal(UpperBoundPrediction, prediction.Kind)
+		assert.Equal(testTime.Add((8*24+12)*time.Hour), prediction.Earliest)
+	})
+
+	t.Run("3 incursions, 2 respawning", func(t *testing.T) {
+		testSubject.respawningIncursions = IncursionList{
+			{State: Respawning, StateChanged: testTime.Add(-13 * time.Hour)},
+			{State: Respawning, StateChanged: testTime.Add(-40 * time.Hour)}, // Window already closed
+		}
+
+		prediction := testSubject.nextRespawn(testTime)
+		assert.Equal(InWindowPrediction, prediction.Kind)
+		assert.Equal(testTime.Add(-time.Hour), prediction.Earliest)
+		assert.Equal(testTime.Add(23*time.Hour), prediction.Latest)
+	})
+}
+
+func TestRespawnWindows(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	testTime := time.Now()
+
+	testSubject := SpawnTracker{
+		currentIncursions: IncursionList{
+			{State: Established, StateChanged: testTime},
+			{State: Withdrawing, StateChanged: testTime},
+			{},
+		},
+		respawningIncursions: IncursionList{
+			{State: Respawning, StateChanged: testTime.Add(-time.Hour)},
+		},
+	}
+
+	windows := testSubject.respawnWindows()
+	assert.Len(windows, 3)
+
+	assert.Equal(Respawning, windows[0].Incursion.State)
+	assert.Equal(testTime.Add(11*time.Hour), windows[0].Start)
+	assert.Equal(testTime.Add(35*time.Hour), windows[0].End)
+	assert.False(windows[0].UpperBound)
+
+	assert.Equal(testTime.Add(36*time.Hour), windows[1].Start)
+
+	assert.Equal(testTime.Add((8*24+12)*time.Hour), windows[2].Start)
+	assert.True(windows[2].UpperBound)
 }
 
 func TestIncursionManagement(t *testing.T) {
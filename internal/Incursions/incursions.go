@@ -3,7 +3,9 @@ package incursions
 import (
 	"IncursionBot/internal/ESI"
 	logging "IncursionBot/internal/Logging"
+	"context"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 )
@@ -42,11 +44,11 @@ type NamedItem struct {
 	ID   int
 }
 
-func CreateNamedItem(id int, esi *ESI.ESIClient) (item NamedItem) {
+func CreateNamedItem(ctx context.Context, id int, esi *ESI.ESIClient) (item NamedItem) {
 	item.ID = id
 	item.Name = "Unknown" // Default value
 
-	name, err := esi.GetNames([]int{id})
+	name, err := esi.GetNames(ctx, []int{id})
 
 	if err != nil {
 		logging.Errorf("Error occurred getting name for %d: %v", id, err)
@@ -138,3 +140,23 @@ func (incursion *Incursion) Update(influence float64, state IncursionState) bool
 
 	return false
 }
+
+// Finds the threshold crossed by influence changing from previous to current, in either direction.
+// If several were crossed, the one closest to the current influence is returned.
+func CrossedThreshold(thresholds []float64, previous float64, current float64) (float64, bool) {
+	var crossed float64
+	found := false
+
+	for _, threshold := range thresholds {
+		if (previous < threshold) == (current < threshold) {
+			continue
+		}
+
+		if !found || math.Abs(current-threshold) < math.Abs(current-crossed) {
+			crossed = threshold
+			found = true
+		}
+	}
+
+	return crossed, found
+}
@@ -37,3 +37,33 @@ func TestFind(t *testing.T) {
 
 	assert.Empty(t, testList.Find(newIncursion))
 }
+
+func TestCrossedThreshold(t *testing.T) {
+	assert := assert.New(t)
+	thresholds := []float64{.25, .5, .75}
+
+	_, crossed := CrossedThreshold(thresholds, .3, .4)
+	assert.False(crossed)
+
+	threshold, crossed := CrossedThreshold(thresholds, .4, .6)
+	assert.True(crossed)
+	assert.Equal(.5, threshold)
+
+	t.Run("Falling", func(t *testing.T) {
+		threshold, crossed := CrossedThreshold(thresholds, .8, .45)
+		assert.True(crossed)
+		assert.Equal(.5, threshold)
+	})
+
+	t.Run("Exactly on threshold", func(t *testing.T) {
+		threshold, crossed := CrossedThreshold(thresholds, .7, .75)
+		assert.True(crossed)
+		assert.Equal(.75, threshold)
+
+		_, crossed = CrossedThreshold(thresholds, .75, .75)
+		assert.False(crossed)
+	})
+
+	_, crossed = CrossedThreshold(nil, 0, 1)
+	assert.False(crossed)
+}
@@ -0,0 +1,168 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+const activityRetention time.Duration = time.Hour * 24 * 14 // How long events are kept for activity reports
+
+// Summary of incursion activity over a period
+type ActivityReport struct {
+	Since           time.Time
+	Spawns          incursions.IncursionList // Incursions that spawned during the period
+	HomeSpawns      incursions.IncursionList // Spawns in a home region
+	Despawns        int                      // Number of incursions that despawned during the period
+	EstablishedTime time.Duration            // Total time all incursions spent established during the period
+}
+
+// Records events so activity over a recent period can be reported, optionally saving them to a file so reports and
+// feeds still cover what happened before a restart
+type ActivityTracker struct {
+	File string // JSON file the events are saved to, not saved if empty
+
+	mut    sync.Mutex
+	events []Event
+}
+
+// Loads the saved events from the file, if there is one
+func (tracker *ActivityTracker) Load() error {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
+	tracker.events = nil
+	if tracker.File == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(tracker.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, &tracker.events)
+	if err != nil {
+		return err
+	}
+
+	tracker.expire(time.Now())
+	return nil
+}
+
+func (tracker *ActivityTracker) Notify(event Event) {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
+	tracker.events = append(tracker.events, event)
+	tracker.expire(event.Time)
+	tracker.save()
+}
+
+// Drops anything too old to matter. Must be called with mut locked.
+func (tracker *ActivityTracker) expire(now time.Time) {
+	cutoff := now.Add(-activityRetention)
+	for len(tracker.events) > 0 && tracker.events[0].Time.Before(cutoff) {
+		tracker.events = tracker.events[1:]
+	}
+}
+
+// Must be called with mut locked
+func (tracker *ActivityTracker) save() {
+	if tracker.File == "" {
+		return
+	}
+
+	data, err := json.Marshal(tracker.events)
+	if err == nil {
+		err = os.WriteFile(tracker.File, data, 0600)
+	}
+	if err != nil {
+		logging.Errorf("Failed to save recent activity to %s: %v", tracker.File, err)
+	}
+}
+
+// Gets the events since the given time, most recent first
+func (tracker *ActivityTracker) Events(since time.Time) []Event {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
+	var result []Event
+	for i := len(tracker.events) - 1; i >= 0 && !tracker.events[i].Time.Before(since); i-- {
+		result = append(result, tracker.events[i])
+	}
+
+	return result
+}
+
+// Reports activity between the given time and now. Only incursions seen by the bot are counted.
+func (tracker *ActivityTracker) Report(since time.Time) ActivityReport {
+	return tracker.reportAt(since, time.Now())
+}
+
+func (tracker *ActivityTracker) reportAt(since time.Time, now time.Time) ActivityReport {
+	tracker.mut.Lock()
+	defer tracker.mut.Unlock()
+
+	report := ActivityReport{Since: since}
+	establishedSince := make(map[int]time.Time) // Staging system to the time it became established
+
+	endEstablished := func(stagingID int, end time.Time) {
+		start, pres := establishedSince[stagingID]
+		if !pres {
+			return
+		}
+
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(start) {
+			report.EstablishedTime += end.Sub(start)
+		}
+		delete(establishedSince, stagingID)
+	}
+
+	for _, event := range tracker.events {
+		stagingID := event.Incursion.Layout.StagingSystem.ID
+
+		switch event.Kind {
+		case ReminderEvent, InfluenceEvent:
+			continue
+		case SpawnEvent:
+			if !event.Time.Before(since) {
+				report.Spawns = append(report.Spawns, event.Incursion)
+				if event.Home {
+					report.HomeSpawns = append(report.HomeSpawns, event.Incursion)
+				}
+			}
+		case DespawnEvent:
+			if !event.Time.Before(since) {
+				report.Despawns++
+			}
+			endEstablished(stagingID, event.Time)
+			continue
+		}
+
+		if event.Incursion.State == incursions.Established {
+			if _, pres := establishedSince[stagingID]; !pres {
+				establishedSince[stagingID] = event.Time
+			}
+		} else {
+			endEstablished(stagingID, event.Time)
+		}
+	}
+
+	// Anything still established counts up until now
+	for stagingID := range establishedSince {
+		endEstablished(stagingID, now)
+	}
+
+	return report
+}
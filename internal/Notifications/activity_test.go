@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventAt(kind EventKind, stagingID int, state incursions.IncursionState, home bool, at time.Time) Event {
+	return Event{
+		Kind: kind,
+		Incursion: incursions.Incursion{
+			Layout: incursions.IncursionLayout{StagingSystem: incursions.NamedItem{ID: stagingID}},
+			State:  state,
+		},
+		Home: home,
+		Time: at,
+	}
+}
+
+func TestActivityReport(t *testing.T) {
+	assert := assert.New(t)
+	var testSubject ActivityTracker
+	start := atTime(0, 0)
+
+	testSubject.Notify(eventAt(SpawnEvent, 1, incursions.Established, false, start.Add(-2*time.Hour)))
+	testSubject.Notify(eventAt(SpawnEvent, 2, incursions.Established, true, start.Add(time.Hour)))
+	testSubject.Notify(eventAt(StateChangeEvent, 1, incursions.Mobilizing, false, start.Add(3*time.Hour)))
+	testSubject.Notify(eventAt(DespawnEvent, 1, incursions.Mobilizing, false, start.Add(4*time.Hour)))
+
+	report := testSubject.reportAt(start, start.Add(5*time.Hour))
+
+	assert.Len(report.Spawns, 1)
+	assert.Len(report.HomeSpawns, 1)
+	assert.Equal(1, report.Despawns)
+	// 3 hours for the first incursion counted from the start of the period, 4 for the second which is still established
+	assert.Equal(7*time.Hour, report.EstablishedTime)
+}
+
+func TestActivityRetention(t *testing.T) {
+	var testSubject ActivityTracker
+	start := atTime(0, 0)
+
+	testSubject.Notify(eventAt(SpawnEvent, 1, incursions.Mobilizing, false, start))
+	testSubject.Notify(eventAt(SpawnEvent, 2, incursions.Mobilizing, false, start.Add(activityRetention+time.Hour)))
+
+	assert.Len(t, testSubject.events, 1)
+}
+
+func TestActivityEvents(t *testing.T) {
+	assert := assert.New(t)
+	var testSubject ActivityTracker
+	start := atTime(0, 0)
+
+	testSubject.Notify(eventAt(SpawnEvent, 1, incursions.Mobilizing, false, start))
+	testSubject.Notify(eventAt(SpawnEvent, 2, incursions.Mobilizing, false, start.Add(time.Hour)))
+	testSubject.Notify(eventAt(DespawnEvent, 1, incursions.Mobilizing, false, start.Add(time.Hour*2)))
+
+	events := testSubject.Events(start.Add(time.Hour))
+	assert.Len(events, 2)
+	assert.Equal(DespawnEvent, events[0].Kind)
+	assert.Equal(2, events[1].Incursion.Layout.StagingSystem.ID)
+}
+
+func TestActivitySaved(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	file := filepath.Join(t.TempDir(), "activity.json")
+	now := time.Now().Truncate(time.Second)
+
+	tracker := ActivityTracker{File: file}
+	assert.NoError(tracker.Load())
+	tracker.Notify(eventAt(SpawnEvent, 1, incursions.Established, true, now.Add(-activityRetention-time.Hour)))
+	tracker.Notify(eventAt(SpawnEvent, 2, incursions.Established, true, now.Add(-time.Hour)))
+	tracker.Notify(eventAt(StateChangeEvent, 2, incursions.Mobilizing, true, now))
+
+	// After a restart the events are still there for reports and feeds, apart from any too old to matter
+	reloaded := ActivityTracker{File: file}
+	assert.NoError(reloaded.Load())
+	ids := func(events []Event) []string {
+		var result []string
+		for _, event := range events {
+			result = append(result, event.ID())
+		}
+		return result
+	}
+	assert.Len(reloaded.Events(time.Time{}), 2)
+	assert.Equal(ids(tracker.Events(time.Time{})), ids(reloaded.Events(time.Time{})))
+
+	report := reloaded.reportAt(now.Add(-time.Hour*2), now)
+	assert.Len(report.HomeSpawns, 1)
+	assert.Equal(time.Hour, report.EstablishedTime)
+
+	t.Run("Expired on load", func(t *testing.T) {
+		stale := ActivityTracker{File: file}
+		stale.Notify(eventAt(SpawnEvent, 3, incursions.Established, false, now.Add(-activityRetention-time.Hour)))
+
+		reloaded := ActivityTracker{File: file}
+		assert.NoError(reloaded.Load())
+		assert.Empty(reloaded.Events(time.Time{}))
+	})
+}
@@ -0,0 +1,140 @@
+package notifications
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"sync"
+	"time"
+)
+
+// Formats a single event into a message
+type EventFormatter func(Event) string
+
+// Formats the events held during quiet hours into a single digest message
+type DigestFormatter func([]Event) string
+
+// A chat channel that receives notifications, holding non-critical events during its quiet hours and dropping reminders
+type Destination struct {
+	Name             string       // Name of the channel, used for logging
+	QuietHours       []QuietHours // Windows during which events are held for the digest
+	HomeSpawnsBypass bool         // Send home region spawns immediately, even during quiet hours
+	Reminders        bool         // Receive reminder events
+	Send             func(message string) error
+	FormatEvent      EventFormatter
+	FormatDigest     DigestFormatter
+	now              func() time.Time // Overridable clock for testing
+	heldMut          sync.Mutex
+	held             []Event
+	digestTimer      *time.Timer
+}
+
+func (dest *Destination) Notify(event Event) {
+	if event.Kind == ReminderEvent && !dest.Reminders {
+		return
+	}
+
+	if event.Kind == InfluenceEvent {
+		return // Only sent to webhooks
+	}
+
+	if !dest.isCritical(event) && dest.InQuietHours() {
+		if event.Kind == ReminderEvent {
+			return // By the time the digest is sent the reminder would be about something that already happened
+		}
+		dest.hold(event)
+		return
+	}
+
+	dest.send(dest.FormatEvent(event))
+}
+
+// Checks if the destination is currently in any of its quiet hours windows
+func (dest *Destination) InQuietHours() bool {
+	now := dest.currentTime()
+
+	for _, window := range dest.QuietHours {
+		if window.Contains(now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sends the digest of any held events, or reschedules it if the destination is still in quiet hours
+func (dest *Destination) FlushDigest() {
+	dest.heldMut.Lock()
+	if dest.InQuietHours() && len(dest.held) > 0 {
+		dest.scheduleDigest()
+		dest.heldMut.Unlock()
+		return
+	}
+
+	held := dest.held
+	dest.held = nil
+	dest.digestTimer = nil
+	dest.heldMut.Unlock()
+
+	if len(held) == 0 {
+		return
+	}
+
+	logging.Infof("Quiet hours over for %s, sending digest of %d events", dest.Name, len(held))
+	dest.send(dest.FormatDigest(held))
+}
+
+// Sends a message straight to the destination, ignoring quiet hours
+func (dest *Destination) Post(message string) {
+	dest.send(message)
+}
+
+func (dest *Destination) isCritical(event Event) bool {
+	return dest.HomeSpawnsBypass && event.Home && event.Kind == SpawnEvent
+}
+
+func (dest *Destination) hold(event Event) {
+	dest.heldMut.Lock()
+	defer dest.heldMut.Unlock()
+
+	logging.Infof("Holding %s event for %s until quiet hours end", event.Kind, dest.Name)
+	dest.held = append(dest.held, event)
+
+	if dest.digestTimer == nil {
+		dest.scheduleDigest()
+	}
+}
+
+// Schedules the digest for the end of the current quiet hours. Must be called with heldMut locked.
+func (dest *Destination) scheduleDigest() {
+	now := dest.currentTime()
+	var digestTime time.Time
+
+	for _, window := range dest.QuietHours {
+		if window.Contains(now) {
+			end := window.NextEnd(now)
+			if end.After(digestTime) {
+				digestTime = end
+			}
+		}
+	}
+
+	dest.digestTimer = time.AfterFunc(digestTime.Sub(now), dest.FlushDigest)
+}
+
+func (dest *Destination) send(message string) {
+	if message == "" {
+		return
+	}
+
+	err := dest.Send(message)
+	if err != nil {
+		logging.Errorf("Failed to send message to %s: %v", dest.Name, err)
+	}
+}
+
+func (dest *Destination) currentTime() time.Time {
+	if dest.now == nil {
+		return time.Now()
+	}
+
+	return dest.now()
+}
@@ -0,0 +1,74 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestinationQuietHours(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	var sent []string
+	currentTime := atTime(3, 0)
+	window, _ := ParseQuietHours("01:00", "07:00")
+
+	dest := Destination{
+		Name:             "test",
+		QuietHours:       []QuietHours{window},
+		HomeSpawnsBypass: true,
+		Send: func(message string) error {
+			sent = append(sent, message)
+			return nil
+		},
+		FormatEvent:  func(e Event) string { return string(e.Kind) },
+		FormatDigest: func(events []Event) string { return fmt.Sprintf("digest %d", len(events)) },
+		now:          func() time.Time { return currentTime },
+	}
+
+	t.Run("Held during quiet hours", func(t *testing.T) {
+		dest.Notify(NewEvent(StateChangeEvent, incursions.Incursion{}, true))
+		dest.Notify(NewEvent(SpawnEvent, incursions.Incursion{}, false))
+
+		assert.Empty(sent)
+		assert.Len(dest.held, 2)
+	})
+
+	t.Run("Home spawn bypasses", func(t *testing.T) {
+		dest.Notify(NewEvent(SpawnEvent, incursions.Incursion{}, true))
+
+		assert.Equal([]string{"spawn"}, sent)
+		assert.Len(dest.held, 2)
+	})
+
+	t.Run("Reminders dropped during quiet hours", func(t *testing.T) {
+		dest.Reminders = true
+		dest.Notify(NewEvent(ReminderEvent, incursions.Incursion{}, true))
+
+		assert.Equal([]string{"spawn"}, sent)
+		assert.Len(dest.held, 2)
+	})
+
+	t.Run("Digest waits for the window to end", func(t *testing.T) {
+		dest.FlushDigest()
+		assert.Len(sent, 1)
+
+		currentTime = atTime(7, 0)
+		dest.FlushDigest()
+		assert.Equal([]string{"spawn", "digest 2"}, sent)
+		assert.Empty(dest.held)
+	})
+
+	t.Run("Sent outside quiet hours", func(t *testing.T) {
+		dest.Notify(NewEvent(DespawnEvent, incursions.Incursion{}, false))
+		assert.Equal("despawn", sent[len(sent)-1])
+
+		dest.FlushDigest()
+		assert.Len(sent, 3)
+	})
+}
@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+type EventKind string
+
+const (
+	SpawnEvent       EventKind = "spawn"
+	StateChangeEvent EventKind = "stateChange"
+	DespawnEvent     EventKind = "despawn"
+	ReminderEvent    EventKind = "reminder"
+	InfluenceEvent   EventKind = "influence"
+)
+
+// Something that happened to an incursion that people may want to hear about
+type Event struct {
+	Kind      EventKind
+	Incursion incursions.Incursion // Incursion as of the event
+	Home      bool                 // True if the incursion is in a home region
+	Time      time.Time            // Time the event was detected
+	Reminder  incursions.Reminder  // Set for reminder events only
+	Threshold float64              // Influence threshold crossed, set for influence events only
+}
+
+func NewEvent(kind EventKind, incursion incursions.Incursion, home bool) Event {
+	return Event{
+		Kind:      kind,
+		Incursion: incursion,
+		Home:      home,
+		Time:      time.Now(),
+	}
+}
+
+// Creates an event for a reminder timer going off
+func NewReminderEvent(reminder incursions.Reminder, home bool) Event {
+	event := NewEvent(ReminderEvent, reminder.Incursion, home)
+	event.Reminder = reminder
+	return event
+}
+
+// Creates an event for an incursion's influence crossing one of the configured thresholds
+func NewInfluenceEvent(incursion incursions.Incursion, home bool, threshold float64) Event {
+	event := NewEvent(InfluenceEvent, incursion, home)
+	event.Threshold = threshold
+	return event
+}
+
+// Stable identifier for the event, the same for every sink it is sent to
+func (event Event) ID() string {
+	key := fmt.Sprintf("%s/%d/%s/%d", event.Kind, event.Incursion.Layout.StagingSystem.ID, event.Incursion.State, event.Time.UnixNano())
+	if event.Kind == ReminderEvent {
+		key += "/" + string(event.Reminder.Kind)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Receives events as they happen
+type Sink interface {
+	Notify(event Event)
+}
+
+// Sends each event to every registered sink
+type Dispatcher struct {
+	sinks []Sink
+}
+
+func (d *Dispatcher) AddSink(sink Sink) {
+	d.sinks = append(d.sinks, sink)
+}
+
+func (d *Dispatcher) Notify(event Event) {
+	for _, sink := range d.sinks {
+		sink.Notify(event)
+	}
+}
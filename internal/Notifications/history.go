@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+const historyLimit int = 1000 // Spawns kept in the history, oldest are dropped first
+
+// A state an incursion was in and when it was first seen in it
+type StateRecord struct {
+	State incursions.IncursionState `json:"state"`
+	Since time.Time                 `json:"since"`
+}
+
+// The lifetime of a single spawn, as seen by the bot
+type SpawnRecord struct {
+	StagingSystemID int                      `json:"stagingSystemId"`
+	StagingSystem   string                   `json:"stagingSystem"`
+	Constellation   string                   `json:"constellation"`
+	Region          string                   `json:"region"`
+	Security        incursions.SecurityClass `json:"security"`
+	Home            bool                     `json:"home"`
+	FirstSeen       time.Time                `json:"firstSeen"`
+	Spawned         *time.Time               `json:"spawned,omitempty"`   // Unset if it was already up when first seen
+	Despawned       *time.Time               `json:"despawned,omitempty"` // Unset while the spawn is active
+	Influence       float64                  `json:"influence"`           // Last known influence
+	States          []StateRecord            `json:"states"`
+}
+
+// Checks if the spawn is still up
+func (record *SpawnRecord) Active() bool {
+	return record.Despawned == nil
+}
+
+// Records every spawn the bot sees, optionally saving them to a file
+type History struct {
+	File string // JSON file the history is saved to, not saved if empty
+
+	mut     sync.Mutex
+	records []SpawnRecord
+}
+
+// Loads the saved history from the file, if there is one
+func (h *History) Load() error {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	h.records = nil
+	if h.File == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(h.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &h.records)
+}
+
+// Gets every recorded spawn, most recent first
+func (h *History) Records() []SpawnRecord {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	result := make([]SpawnRecord, 0, len(h.records))
+	for i := len(h.records) - 1; i >= 0; i-- {
+		result = append(result, h.records[i])
+	}
+
+	return result
+}
+
+func (h *History) Notify(event Event) {
+	if event.Kind == ReminderEvent {
+		return
+	}
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	record := h.activeRecord(event.Incursion, event.Time)
+	record.Home = event.Home
+	record.Influence = event.Incursion.Influence
+
+	switch event.Kind {
+	case SpawnEvent:
+		spawned := event.Time
+		record.Spawned = &spawned
+	case DespawnEvent:
+		despawned := event.Time
+		record.Despawned = &despawned
+	}
+	record.addState(event.Incursion.State, event.Time)
+
+	h.save()
+}
+
+// Brings the history in line with the full list of current incursions. Incursions that were already up when the
+// bot started are added, and any that disappeared while it was down are marked as despawned.
+func (h *History) Observe(list incursions.IncursionList, now time.Time) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	for _, incursion := range list {
+		record := h.activeRecord(incursion, now)
+		record.Influence = incursion.Influence
+		record.addState(incursion.State, now)
+	}
+
+	for i := range h.records {
+		record := &h.records[i]
+		if record.Active() && !listContains(list, record.StagingSystemID) {
+			logging.Infof("%s despawned without being seen, marking it as despawned now", record.StagingSystem)
+			despawned := now
+			record.Despawned = &despawned
+		}
+	}
+
+	h.save()
+}
+
+// Finds the record of the incursion's current spawn, creating one if there isn't one. Must be called with mut locked.
+func (h *History) activeRecord(incursion incursions.Incursion, now time.Time) *SpawnRecord {
+	stagingID := incursion.Layout.StagingSystem.ID
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].StagingSystemID == stagingID && h.records[i].Active() {
+			return &h.records[i]
+		}
+	}
+
+	h.records = append(h.records, SpawnRecord{
+		StagingSystemID: stagingID,
+		StagingSystem:   incursion.Layout.StagingSystem.Name,
+		Constellation:   incursion.Constellation.Name,
+		Region:          incursion.Region.Name,
+		Security:        incursion.Security,
+		FirstSeen:       now,
+	})
+	if len(h.records) > historyLimit {
+		h.records = h.records[len(h.records)-historyLimit:]
+	}
+
+	return &h.records[len(h.records)-1]
+}
+
+func (record *SpawnRecord) addState(state incursions.IncursionState, now time.Time) {
+	if len(record.States) > 0 && record.States[len(record.States)-1].State == state {
+		return
+	}
+
+	record.States = append(record.States, StateRecord{State: state, Since: now})
+}
+
+// Must be called with mut locked
+func (h *History) save() {
+	if h.File == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(h.records, "", "  ")
+	if err == nil {
+		err = os.WriteFile(h.File, data, 0600)
+	}
+	if err != nil {
+		logging.Errorf("Failed to save spawn history to %s: %v", h.File, err)
+	}
+}
+
+func listContains(list incursions.IncursionList, stagingID int) bool {
+	for _, incursion := range list {
+		if incursion.Layout.StagingSystem.ID == stagingID {
+			return true
+		}
+	}
+
+	return false
+}
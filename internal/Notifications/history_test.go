@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stagedIncursion(id int, state incursions.IncursionState) incursions.Incursion {
+	return incursions.Incursion{
+		Layout: incursions.IncursionLayout{StagingSystem: incursions.NamedItem{ID: id, Name: "System"}},
+		State:  state,
+	}
+}
+
+func TestHistory(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	file := filepath.Join(t.TempDir(), "history.json")
+	start := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	history := History{File: file}
+	assert.NoError(history.Load())
+
+	// Already up when the bot started
+	history.Observe(incursions.IncursionList{stagedIncursion(1, incursions.Established)}, start)
+
+	spawn := NewEvent(SpawnEvent, stagedIncursion(2, incursions.Established), true)
+	spawn.Time = start.Add(time.Hour)
+	history.Notify(spawn)
+
+	mobilizing := NewEvent(StateChangeEvent, stagedIncursion(2, incursions.Mobilizing), true)
+	mobilizing.Time = start.Add(time.Hour * 2)
+	history.Notify(mobilizing)
+
+	records := history.Records()
+	assert.Len(records, 2)
+	assert.Equal(2, records[0].StagingSystemID)
+	assert.True(records[0].Home)
+	assert.Equal(spawn.Time, *records[0].Spawned)
+	assert.Equal([]StateRecord{
+		{State: incursions.Established, Since: spawn.Time},
+		{State: incursions.Mobilizing, Since: mobilizing.Time},
+	}, records[0].States)
+	assert.Nil(records[1].Spawned)
+	assert.True(records[1].Active())
+
+	t.Run("Despawn", func(t *testing.T) {
+		despawn := NewEvent(DespawnEvent, stagedIncursion(2, incursions.Mobilizing), true)
+		history.Notify(despawn)
+
+		records := history.Records()
+		assert.False(records[0].Active())
+		assert.Len(records[0].States, 2)
+	})
+
+	t.Run("Respawn in the same system is a new record", func(t *testing.T) {
+		history.Notify(NewEvent(SpawnEvent, stagedIncursion(2, incursions.Established), false))
+
+		records := history.Records()
+		assert.Len(records, 3)
+		assert.True(records[0].Active())
+		assert.False(records[1].Active())
+	})
+
+	t.Run("Missed despawns", func(t *testing.T) {
+		history.Observe(incursions.IncursionList{stagedIncursion(2, incursions.Established)}, start.Add(time.Hour*3))
+
+		records := history.Records()
+		assert.Equal(1, records[2].StagingSystemID)
+		assert.Equal(start.Add(time.Hour*3), *records[2].Despawned)
+		assert.True(records[0].Active())
+	})
+
+	reloaded := History{File: file}
+	assert.NoError(reloaded.Load())
+	loaded := reloaded.Records()
+	assert.Len(loaded, 3)
+	assert.True(loaded[0].Active())
+	assert.True(loaded[2].Despawned.Equal(start.Add(time.Hour * 3)))
+}
@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	"time"
+)
+
+// Event as sent to webhooks and live feeds
+type EventPayload struct {
+	ID        string                  `json:"id"`
+	Event     EventKind               `json:"event"`
+	Time      time.Time               `json:"time"`
+	Home      bool                    `json:"home"`
+	Threshold float64                 `json:"threshold,omitempty"` // Set for influence events
+	Reminder  incursions.ReminderKind `json:"reminder,omitempty"`  // Set for reminder events
+	Incursion IncursionPayload        `json:"incursion"`
+}
+
+// Incursion as sent to webhooks and live feeds
+type IncursionPayload struct {
+	Constellation   string                    `json:"constellation"`
+	ConstellationID int                       `json:"constellationId"`
+	Region          string                    `json:"region"`
+	RegionID        int                       `json:"regionId"`
+	StagingSystem   string                    `json:"stagingSystem"`
+	StagingSystemID int                       `json:"stagingSystemId"`
+	HQSystem        string                    `json:"hqSystem"`
+	VanguardSystems []string                  `json:"vanguardSystems"`
+	AssaultSystems  []string                  `json:"assaultSystems"`
+	State           incursions.IncursionState `json:"state"`
+	Influence       float64                   `json:"influence"`
+	Security        incursions.SecurityClass  `json:"security"`
+	SecStatus       float64                   `json:"secStatus"`
+	Distance        int                       `json:"distance"`
+	SovOwner        string                    `json:"sovOwner,omitempty"`
+	StateChanged    *time.Time                `json:"stateChanged,omitempty"`
+	Despawn         *time.Time                `json:"despawn,omitempty"` // Latest possible despawn for established incursions
+}
+
+func NewEventPayload(event Event) EventPayload {
+	return EventPayload{
+		ID:        event.ID(),
+		Event:     event.Kind,
+		Time:      event.Time.UTC(),
+		Home:      event.Home,
+		Threshold: event.Threshold,
+		Reminder:  event.Reminder.Kind,
+		Incursion: NewIncursionPayload(event.Incursion),
+	}
+}
+
+func NewIncursionPayload(inc incursions.Incursion) IncursionPayload {
+	payload := IncursionPayload{
+		Constellation:   inc.Constellation.Name,
+		ConstellationID: inc.Constellation.ID,
+		Region:          inc.Region.Name,
+		RegionID:        inc.Region.ID,
+		StagingSystem:   inc.Layout.StagingSystem.Name,
+		StagingSystemID: inc.Layout.StagingSystem.ID,
+		HQSystem:        inc.Layout.HQSystem.Name,
+		VanguardSystems: itemNames(inc.Layout.VanguardSystems),
+		AssaultSystems:  itemNames(inc.Layout.AssaultSystems),
+		State:           inc.State,
+		Influence:       inc.Influence,
+		Security:        inc.Security,
+		SecStatus:       inc.SecStatus,
+		Distance:        inc.Distance,
+		SovOwner:        inc.SovOwner,
+	}
+
+	if !inc.StateChanged.IsZero() {
+		stateChanged := inc.StateChanged.UTC()
+		payload.StateChanged = &stateChanged
+
+		despawn, err := inc.TimeLeftInSpawn()
+		if err == nil {
+			despawn = despawn.UTC()
+			payload.Despawn = &despawn
+		}
+	}
+
+	return payload
+}
+
+func itemNames(items []incursions.NamedItem) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+
+	return names
+}
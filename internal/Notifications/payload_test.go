@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventPayload(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	changed := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	incursion := incursions.Incursion{
+		Constellation: incursions.NamedItem{Name: "Constellation", ID: 1},
+		Region:        incursions.NamedItem{Name: "Region", ID: 2},
+		Layout: incursions.IncursionLayout{
+			StagingSystem:  incursions.NamedItem{Name: "Staging", ID: 3},
+			AssaultSystems: []incursions.NamedItem{{Name: "Assault"}},
+		},
+		State:        incursions.Mobilizing,
+		Influence:    .5,
+		Security:     incursions.NullSec,
+		StateChanged: changed,
+	}
+	event := NewInfluenceEvent(incursion, true, .5)
+
+	payload := NewEventPayload(event)
+	assert.Equal(event.ID(), payload.ID)
+	assert.Equal(InfluenceEvent, payload.Event)
+	assert.Equal(.5, payload.Threshold)
+	assert.Equal("Staging", payload.Incursion.StagingSystem)
+	assert.Equal([]string{"Assault"}, payload.Incursion.AssaultSystems)
+	assert.Equal([]string{}, payload.Incursion.VanguardSystems)
+	assert.Equal(changed.Add(time.Hour*72), *payload.Incursion.Despawn)
+
+	t.Run("Unknown state change time", func(t *testing.T) {
+		incursion.StateChanged = time.Time{}
+		payload := NewEventPayload(NewEvent(SpawnEvent, incursion, false))
+
+		assert.Nil(payload.Incursion.StateChanged)
+		assert.Nil(payload.Incursion.Despawn)
+	})
+}
+
+func TestEventID(t *testing.T) {
+	assert := assert.New(t)
+
+	event := NewEvent(SpawnEvent, incursions.Incursion{}, false)
+	assert.Equal(event.ID(), event.ID())
+
+	other := event
+	other.Kind = DespawnEvent
+	assert.NotEqual(event.ID(), other.ID())
+}
@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	"fmt"
+	"time"
+)
+
+const timeOfDayFormat string = "15:04"
+
+// Daily window, in EVE time, during which non-critical events are held. Windows may cross midnight.
+type QuietHours struct {
+	Start time.Duration // Offset from midnight the window starts
+	End   time.Duration // Offset from midnight the window ends
+}
+
+// Parses a quiet hours window from two HH:MM EVE times
+func ParseQuietHours(start string, end string) (QuietHours, error) {
+	var window QuietHours
+
+	startTime, err := time.Parse(timeOfDayFormat, start)
+	if err != nil {
+		return window, fmt.Errorf("invalid quiet hours start %q: %w", start, err)
+	}
+
+	endTime, err := time.Parse(timeOfDayFormat, end)
+	if err != nil {
+		return window, fmt.Errorf("invalid quiet hours end %q: %w", end, err)
+	}
+
+	window.Start = timeOfDay(startTime)
+	window.End = timeOfDay(endTime)
+	return window, nil
+}
+
+// Checks if the given time falls inside the window
+func (window QuietHours) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+
+	if window.Start <= window.End {
+		return offset >= window.Start && offset < window.End
+	}
+
+	// Window crosses midnight
+	return offset >= window.Start || offset < window.End
+}
+
+// Gets the next time the window ends after the given time
+func (window QuietHours) NextEnd(t time.Time) time.Time {
+	t = t.UTC()
+	midnight := t.Truncate(24 * time.Hour)
+	end := midnight.Add(window.End)
+
+	if !end.After(t) {
+		end = end.Add(24 * time.Hour)
+	}
+
+	return end
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return timeOfDay(t.UTC())
+}
+
+func timeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
@@ -0,0 +1,50 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func atTime(hour int, minute int) time.Time {
+	return time.Date(2024, time.March, 5, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseQuietHours(t *testing.T) {
+	assert := assert.New(t)
+
+	window, err := ParseQuietHours("22:30", "06:00")
+	assert.NoError(err)
+	assert.Equal(22*time.Hour+30*time.Minute, window.Start)
+	assert.Equal(6*time.Hour, window.End)
+
+	_, err = ParseQuietHours("25:00", "06:00")
+	assert.Error(err)
+
+	_, err = ParseQuietHours("01:00", "six")
+	assert.Error(err)
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	assert := assert.New(t)
+
+	sameDay, _ := ParseQuietHours("01:00", "07:00")
+	assert.True(sameDay.Contains(atTime(1, 0)))
+	assert.True(sameDay.Contains(atTime(6, 59)))
+	assert.False(sameDay.Contains(atTime(7, 0)))
+	assert.False(sameDay.Contains(atTime(23, 0)))
+
+	overnight, _ := ParseQuietHours("22:00", "06:00")
+	assert.True(overnight.Contains(atTime(23, 0)))
+	assert.True(overnight.Contains(atTime(3, 0)))
+	assert.False(overnight.Contains(atTime(12, 0)))
+}
+
+func TestQuietHoursNextEnd(t *testing.T) {
+	assert := assert.New(t)
+	overnight, _ := ParseQuietHours("22:00", "06:00")
+
+	assert.Equal(atTime(6, 0).Add(24*time.Hour), overnight.NextEnd(atTime(23, 0)))
+	assert.Equal(atTime(6, 0), overnight.NextEnd(atTime(3, 0)))
+}
@@ -0,0 +1,145 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Events a single user wants sent to them directly
+type Subscription struct {
+	Events   []EventKind                `json:"events"`   // Kinds of event to send
+	Security []incursions.SecurityClass `json:"security"` // Security classes to send events for, all if empty
+	HomeOnly bool                       `json:"homeOnly"` // Only send events for home region incursions
+}
+
+// Checks if the subscription wants the given event
+func (sub Subscription) Matches(event Event) bool {
+	if sub.HomeOnly && !event.Home {
+		return false
+	}
+
+	if len(sub.Security) > 0 && !containsSecurity(sub.Security, event.Incursion.Security) {
+		return false
+	}
+
+	for _, kind := range sub.Events {
+		if kind == event.Kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sends events directly to the users that subscribed to them, optionally saving subscriptions to a file
+type Subscriptions struct {
+	File        string // JSON file subscriptions are saved to, not saved if empty
+	Send        func(user string, message string) error
+	FormatEvent EventFormatter
+
+	mut  sync.Mutex
+	subs map[string]Subscription
+}
+
+// Loads saved subscriptions from the file, if there is one
+func (s *Subscriptions) Load() error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.subs = make(map[string]Subscription)
+	if s.File == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // Nobody has subscribed yet
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &s.subs)
+}
+
+// Gets a user's current subscription
+func (s *Subscriptions) Get(user string) (Subscription, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	sub, pres := s.subs[user]
+	return sub, pres
+}
+
+// Replaces a user's subscription, removing it if it has no events
+func (s *Subscriptions) Set(user string, sub Subscription) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.subs == nil {
+		s.subs = make(map[string]Subscription)
+	}
+
+	if len(sub.Events) == 0 {
+		delete(s.subs, user)
+	} else {
+		s.subs[user] = sub
+	}
+
+	return s.save()
+}
+
+func (s *Subscriptions) Notify(event Event) {
+	s.mut.Lock()
+	var recipients []string
+	for user, sub := range s.subs {
+		if sub.Matches(event) {
+			recipients = append(recipients, user)
+		}
+	}
+	s.mut.Unlock()
+
+	if len(recipients) == 0 {
+		return
+	}
+
+	message := s.FormatEvent(event)
+	if message == "" {
+		return
+	}
+
+	for _, user := range recipients {
+		err := s.Send(user, message)
+		if err != nil {
+			logging.Errorf("Failed to send %s event to subscriber %s: %v", event.Kind, user, err)
+		}
+	}
+}
+
+// Must be called with mut locked
+func (s *Subscriptions) save() error {
+	if s.File == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.subs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.File, data, 0600)
+}
+
+func containsSecurity(list []incursions.SecurityClass, security incursions.SecurityClass) bool {
+	for _, entry := range list {
+		if entry == security {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	null := Event{Kind: SpawnEvent, Incursion: incursions.Incursion{Security: incursions.NullSec}}
+	homeNull := null
+	homeNull.Home = true
+	low := Event{Kind: SpawnEvent, Incursion: incursions.Incursion{Security: incursions.LowSec}}
+	despawn := Event{Kind: DespawnEvent, Incursion: incursions.Incursion{Security: incursions.NullSec}}
+
+	all := Subscription{Events: []EventKind{SpawnEvent}}
+	assert.True(all.Matches(null))
+	assert.True(all.Matches(low))
+	assert.False(all.Matches(despawn))
+
+	nullOnly := Subscription{Events: []EventKind{SpawnEvent}, Security: []incursions.SecurityClass{incursions.NullSec}}
+	assert.True(nullOnly.Matches(null))
+	assert.False(nullOnly.Matches(low))
+
+	homeOnly := Subscription{Events: []EventKind{SpawnEvent}, HomeOnly: true}
+	assert.False(homeOnly.Matches(null))
+	assert.True(homeOnly.Matches(homeNull))
+}
+
+func TestSubscriptions(t *testing.T) {
+	assert := assert.New(t)
+	file := filepath.Join(t.TempDir(), "subscriptions.json")
+
+	sent := make(map[string]string)
+	testSubject := Subscriptions{
+		File:        file,
+		Send:        func(user string, message string) error { sent[user] = message; return nil },
+		FormatEvent: func(e Event) string { return string(e.Kind) },
+	}
+	assert.NoError(testSubject.Load())
+
+	assert.NoError(testSubject.Set("a@test.com", Subscription{Events: []EventKind{SpawnEvent}}))
+	assert.NoError(testSubject.Set("b@test.com", Subscription{Events: []EventKind{DespawnEvent}}))
+
+	testSubject.Notify(Event{Kind: SpawnEvent})
+	assert.Equal(map[string]string{"a@test.com": "spawn"}, sent)
+
+	// Subscriptions survive a reload, and subscribing to nothing unsubscribes
+	assert.NoError(testSubject.Set("b@test.com", Subscription{}))
+	reloaded := Subscriptions{File: file}
+	assert.NoError(reloaded.Load())
+
+	_, pres := reloaded.Get("a@test.com")
+	assert.True(pres)
+	_, pres = reloaded.Get("b@test.com")
+	assert.False(pres)
+}
@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"sync"
+	"time"
+)
+
+// Pushes a changing value, such as a room subject, no more often than its interval.
+// Updates made too soon are delayed, and only the latest value is sent.
+type Throttle[T comparable] struct {
+	Name     string        // Used for logging
+	Interval time.Duration // Minimum time between sends
+	Set      func(value T) error
+
+	mut      sync.Mutex
+	current  T // Last value sent successfully
+	pending  T
+	lastSent time.Time
+	timer    *time.Timer
+}
+
+func (t *Throttle[T]) Update(value T) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.pending = value
+	if t.timer != nil {
+		return // Already waiting, the latest value will be picked up when it fires
+	}
+
+	if value == t.current && !t.lastSent.IsZero() {
+		return
+	}
+
+	wait := time.Until(t.lastSent.Add(t.Interval))
+	if wait <= 0 {
+		t.send()
+		return
+	}
+
+	logging.Debugf("Delaying %s update for %s", t.Name, wait)
+	t.timer = time.AfterFunc(wait, t.flush)
+}
+
+func (t *Throttle[T]) flush() {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.timer = nil
+	if t.pending != t.current {
+		t.send()
+	}
+}
+
+// Must be called with mut locked
+func (t *Throttle[T]) send() {
+	t.lastSent = time.Now()
+
+	err := t.Set(t.pending)
+	if err != nil {
+		logging.Warningf("Failed to update %s: %v", t.Name, err)
+		return
+	}
+
+	t.current = t.pending
+}
@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	var mut sync.Mutex
+	var sent []string
+	var failNext bool
+	testSubject := Throttle[string]{
+		Name:     "test",
+		Interval: 50 * time.Millisecond,
+		Set: func(value string) error {
+			mut.Lock()
+			defer mut.Unlock()
+			if failNext {
+				failNext = false
+				return errors.New("failed")
+			}
+			sent = append(sent, value)
+			return nil
+		},
+	}
+
+	getSent := func() []string {
+		mut.Lock()
+		defer mut.Unlock()
+		return append([]string{}, sent...)
+	}
+
+	testSubject.Update("first")
+	assert.Equal([]string{"first"}, getSent())
+
+	// Too soon, only the latest should be sent once the interval passes
+	testSubject.Update("second")
+	testSubject.Update("third")
+	assert.Equal([]string{"first"}, getSent())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal([]string{"first", "third"}, getSent())
+
+	// Unchanged values are not resent
+	testSubject.Update("third")
+	assert.Len(getSent(), 2)
+
+	// Failed sends are retried on the next update
+	time.Sleep(60 * time.Millisecond)
+	failNext = true
+	testSubject.Update("fourth")
+	time.Sleep(60 * time.Millisecond)
+	testSubject.Update("fourth")
+	assert.Equal([]string{"first", "third", "fourth"}, getSent())
+}
@@ -0,0 +1,141 @@
+package notifications
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Headers set on every webhook request
+const (
+	WebhookEventHeader     string = "X-Incursion-Event"     // Kind of event being sent
+	WebhookIDHeader        string = "X-Incursion-Event-Id"  // Event ID, the same across retries so receivers can drop duplicates
+	WebhookSignatureHeader string = "X-Incursion-Signature" // "sha256=" followed by the hex HMAC-SHA256 of the body
+)
+
+const defaultWebhookAttempts int = 5
+const defaultWebhookRetryDelay time.Duration = time.Second * 2
+const webhookTimeout time.Duration = time.Second * 10
+
+// Events sent to webhooks that don't filter on event kind
+var defaultWebhookEvents = []EventKind{SpawnEvent, StateChangeEvent, InfluenceEvent, DespawnEvent}
+
+// Posts events as JSON to an external URL, retrying with backoff on failure
+type Webhook struct {
+	URL         string
+	Secret      string        // Key requests are signed with, unsigned if empty
+	Filter      Subscription  // Events to post, spawns, state changes, influence and despawns if no events are set
+	MaxAttempts int           // Attempts before giving up on an event, defaults to 5
+	RetryDelay  time.Duration // Delay before the first retry, doubled after every attempt
+	DryRun      bool          // Log the requests instead of sending them
+	Client      *http.Client  // Defaults to a client with a 10 second timeout
+}
+
+func (hook *Webhook) Notify(event Event) {
+	filter := hook.Filter
+	if len(filter.Events) == 0 {
+		filter.Events = defaultWebhookEvents
+	}
+	if !filter.Matches(event) {
+		return
+	}
+
+	body, err := json.Marshal(NewEventPayload(event))
+	if err != nil {
+		logging.Errorf("Failed to encode %s event for webhook %s: %v", event.Kind, hook.URL, err)
+		return
+	}
+
+	if hook.DryRun {
+		logging.Infof("Dry run: not posting %s event to %s: %s", event.Kind, hook.URL, body)
+		return
+	}
+
+	go func() {
+		err := hook.deliver(event, body)
+		if err != nil {
+			logging.Errorf("Giving up on posting %s event %s to %s: %v", event.Kind, event.ID(), hook.URL, err)
+		}
+	}()
+}
+
+// Posts the body, retrying until it is accepted, rejected, or out of attempts
+func (hook *Webhook) deliver(event Event, body []byte) error {
+	attempts := hook.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultWebhookAttempts
+	}
+
+	delay := hook.RetryDelay
+	if delay <= 0 {
+		delay = defaultWebhookRetryDelay
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var retry bool
+		retry, err = hook.post(event, body)
+		if err == nil || !retry {
+			return err
+		}
+
+		if attempt < attempts {
+			logging.Warningf("Posting %s event to %s failed (attempt %d of %d), retrying in %s: %v", event.Kind, hook.URL, attempt, attempts, delay, err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	return err
+}
+
+// Makes a single request. Returns true if a failure is worth retrying.
+func (hook *Webhook) post(event Event, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(event.Kind))
+	req.Header.Set(WebhookIDHeader, event.ID())
+	if hook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.Secret, body))
+	}
+
+	resp, err := hook.client().Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("server returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("request rejected with %s", resp.Status)
+	}
+}
+
+func (hook *Webhook) client() *http.Client {
+	if hook.Client == nil {
+		return &http.Client{Timeout: webhookTimeout}
+	}
+
+	return hook.Client
+}
+
+// Signs a webhook body with the given secret, in the format sent in the signature header
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
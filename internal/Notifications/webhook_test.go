@@ -0,0 +1,109 @@
+package notifications
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDelivery(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	var requests []*http.Request
+	var bodies [][]byte
+	statuses := []int{http.StatusBadGateway, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+
+		w.WriteHeader(statuses[0])
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+	}))
+	defer server.Close()
+
+	hook := Webhook{URL: server.URL, Secret: "secret", MaxAttempts: 3, RetryDelay: time.Millisecond}
+	event := NewEvent(SpawnEvent, incursions.Incursion{Security: incursions.NullSec}, false)
+	body, _ := json.Marshal(NewEventPayload(event))
+
+	t.Run("Retries server errors", func(t *testing.T) {
+		err := hook.deliver(event, body)
+
+		assert.NoError(err)
+		assert.Len(requests, 2)
+		assert.Equal(bodies[0], bodies[1])
+		assert.Equal(event.ID(), requests[1].Header.Get(WebhookIDHeader))
+		assert.Equal("spawn", requests[1].Header.Get(WebhookEventHeader))
+		assert.Equal(SignWebhook("secret", body), requests[1].Header.Get(WebhookSignatureHeader))
+	})
+
+	t.Run("Gives up on rejection", func(t *testing.T) {
+		requests = nil
+		statuses = []int{http.StatusBadRequest}
+
+		err := hook.deliver(event, body)
+		assert.Error(err)
+		assert.Len(requests, 1)
+	})
+
+	t.Run("Gives up after max attempts", func(t *testing.T) {
+		requests = nil
+		statuses = []int{http.StatusServiceUnavailable}
+
+		err := hook.deliver(event, body)
+		assert.Error(err)
+		assert.Len(requests, 3)
+	})
+}
+
+func TestWebhookFilter(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(WebhookEventHeader)
+	}))
+	defer server.Close()
+
+	receive := func() string {
+		select {
+		case kind := <-received:
+			return kind
+		case <-time.After(time.Millisecond * 100):
+			return ""
+		}
+	}
+
+	hook := Webhook{URL: server.URL}
+	hook.Notify(NewEvent(ReminderEvent, incursions.Incursion{}, false))
+	assert.Empty(receive())
+	hook.Notify(NewEvent(DespawnEvent, incursions.Incursion{}, false))
+	assert.Equal("despawn", receive())
+
+	t.Run("Filtered", func(t *testing.T) {
+		hook := Webhook{URL: server.URL, Filter: Subscription{Events: []EventKind{SpawnEvent}, HomeOnly: true}}
+
+		hook.Notify(NewEvent(SpawnEvent, incursions.Incursion{}, false))
+		assert.Empty(receive())
+		hook.Notify(NewEvent(SpawnEvent, incursions.Incursion{}, true))
+		assert.Equal("spawn", receive())
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		hook := Webhook{URL: server.URL, DryRun: true}
+
+		hook.Notify(NewEvent(SpawnEvent, incursions.Incursion{}, true))
+		assert.Empty(receive())
+	})
+}
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits searching for the next run so an impossible schedule (e.g. 31 Feb) can't loop forever
+const maxSearch time.Duration = time.Hour * 24 * 366 * 5
+
+// Standard 5 field cron schedule (minute hour day-of-month month day-of-week), evaluated in EVE time
+type CronSchedule struct {
+	minutes     fieldSet
+	hours       fieldSet
+	daysOfMonth fieldSet
+	months      fieldSet
+	daysOfWeek  fieldSet
+	anyDOM      bool // True if the day of month field was a wildcard
+	anyDOW      bool // True if the day of week field was a wildcard
+}
+
+type fieldSet map[int]bool
+
+type fieldBounds struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteBounds = fieldBounds{"minute", 0, 59}
+	hourBounds   = fieldBounds{"hour", 0, 23}
+	domBounds    = fieldBounds{"day of month", 1, 31}
+	monthBounds  = fieldBounds{"month", 1, 12}
+	dowBounds    = fieldBounds{"day of week", 0, 7} // 0 and 7 are both Sunday
+)
+
+// Parses a cron expression such as "0 11 * * *". Supports *, lists, ranges and steps in each field.
+func ParseCron(expression string) (CronSchedule, error) {
+	var schedule CronSchedule
+	fields := strings.Fields(expression)
+
+	if len(fields) != 5 {
+		return schedule, fmt.Errorf("cron expression %q must have 5 fields, found %d", expression, len(fields))
+	}
+
+	var err error
+	if schedule.minutes, err = parseField(fields[0], minuteBounds); err != nil {
+		return schedule, err
+	}
+	if schedule.hours, err = parseField(fields[1], hourBounds); err != nil {
+		return schedule, err
+	}
+	if schedule.daysOfMonth, err = parseField(fields[2], domBounds); err != nil {
+		return schedule, err
+	}
+	if schedule.months, err = parseField(fields[3], monthBounds); err != nil {
+		return schedule, err
+	}
+	if schedule.daysOfWeek, err = parseField(fields[4], dowBounds); err != nil {
+		return schedule, err
+	}
+
+	if schedule.daysOfWeek[7] {
+		schedule.daysOfWeek[0] = true
+	}
+
+	schedule.anyDOM = fields[2] == "*"
+	schedule.anyDOW = fields[4] == "*"
+	return schedule, nil
+}
+
+// Gets the first time after the given time matching the schedule, or the zero time if there is none
+func (schedule CronSchedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if !schedule.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !schedule.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !schedule.hours[t.Hour()] {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+
+		if !schedule.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// Cron matches either day field when both are restricted, otherwise only the restricted one
+func (schedule CronSchedule) matchesDay(t time.Time) bool {
+	domMatch := schedule.daysOfMonth[t.Day()]
+	dowMatch := schedule.daysOfWeek[int(t.Weekday())]
+
+	if schedule.anyDOM || schedule.anyDOW {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+func parseField(field string, bounds fieldBounds) (fieldSet, error) {
+	result := make(fieldSet)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q in %s field", stepPart, bounds.name)
+			}
+		}
+
+		start, end := bounds.min, bounds.max
+		if rangePart != "*" {
+			lowText, highText, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			start, err = parseValue(lowText, bounds)
+			if err != nil {
+				return nil, err
+			}
+
+			end = start
+			if isRange {
+				end, err = parseValue(highText, bounds)
+				if err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				end = bounds.max
+			}
+
+			if end < start {
+				return nil, fmt.Errorf("invalid range %q in %s field", rangePart, bounds.name)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			result[value] = true
+		}
+	}
+
+	return result, nil
+}
+
+func parseValue(text string, bounds fieldBounds) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil || value < bounds.min || value > bounds.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, must be between %d and %d", text, bounds.name, bounds.min, bounds.max)
+	}
+
+	return value, nil
+}
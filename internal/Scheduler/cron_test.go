@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseCron(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ParseCron("0 11 * * *")
+	assert.NoError(err)
+
+	_, err = ParseCron("*/15 0-6,22,23 1 1-12/2 1-5")
+	assert.NoError(err)
+
+	invalid := []string{"", "0 11 * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"}
+	for _, expression := range invalid {
+		_, err = ParseCron(expression)
+		assert.Error(err, expression)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	assert := assert.New(t)
+
+	daily, _ := ParseCron("0 11 * * *")
+	assert.Equal(date(time.March, 5, 11, 0), daily.Next(date(time.March, 5, 10, 30)))
+	assert.Equal(date(time.March, 6, 11, 0), daily.Next(date(time.March, 5, 11, 0)))
+
+	// 5 March 2024 is a Tuesday
+	weekly, _ := ParseCron("0 11 * * 0")
+	assert.Equal(date(time.March, 10, 11, 0), weekly.Next(date(time.March, 5, 12, 0)))
+
+	sunday, _ := ParseCron("0 11 * * 7")
+	assert.Equal(date(time.March, 10, 11, 0), sunday.Next(date(time.March, 5, 12, 0)))
+
+	steps, _ := ParseCron("*/20 * * * *")
+	assert.Equal(date(time.March, 5, 10, 40), steps.Next(date(time.March, 5, 10, 21)))
+	assert.Equal(date(time.March, 5, 11, 0), steps.Next(date(time.March, 5, 10, 40)))
+
+	// Either day field matches when both are restricted
+	either, _ := ParseCron("0 0 1 * 0")
+	assert.Equal(date(time.March, 10, 0, 0), either.Next(date(time.March, 5, 0, 0)))
+	assert.Equal(date(time.April, 1, 0, 0), either.Next(date(time.March, 31, 0, 0)))
+
+	never, _ := ParseCron("0 0 31 2 *")
+	assert.Zero(never.Next(date(time.March, 5, 0, 0)))
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"sync"
+	"time"
+)
+
+// Decides when a job runs next. Returning the zero time stops the job.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Schedule that runs once at a set time
+type OneShot time.Time
+
+func (at OneShot) Next(after time.Time) time.Time {
+	if time.Time(at).After(after) {
+		return time.Time(at)
+	}
+
+	return time.Time{}
+}
+
+type JobID int
+
+type job struct {
+	name     string
+	schedule Schedule
+	run      func()
+	timer    *time.Timer
+	next     time.Time
+}
+
+// Runs jobs on their schedules. The zero value is ready to use.
+type Scheduler struct {
+	mut    sync.Mutex
+	jobs   map[JobID]*job
+	lastID JobID
+}
+
+// Adds a job to run on the given schedule. Returns an ID that can be used to cancel it.
+func (s *Scheduler) Add(name string, schedule Schedule, run func()) JobID {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.jobs == nil {
+		s.jobs = make(map[JobID]*job)
+	}
+
+	s.lastID++
+	id := s.lastID
+	newJob := &job{name: name, schedule: schedule, run: run}
+	s.jobs[id] = newJob
+	s.arm(id, newJob, time.Now())
+
+	return id
+}
+
+// Runs the given function once at the given time
+func (s *Scheduler) At(name string, at time.Time, run func()) JobID {
+	return s.Add(name, OneShot(at), run)
+}
+
+// Stops a job from running again. Cancelling an unknown or finished job does nothing.
+func (s *Scheduler) Cancel(id JobID) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	existing, pres := s.jobs[id]
+	if !pres {
+		return
+	}
+
+	existing.timer.Stop()
+	delete(s.jobs, id)
+}
+
+// Gets the next time the job will run, or false if it is no longer scheduled
+func (s *Scheduler) NextRun(id JobID) (time.Time, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	existing, pres := s.jobs[id]
+	if !pres {
+		return time.Time{}, false
+	}
+
+	return existing.next, true
+}
+
+// Sets the timer for the next run of the job, removing the job if it has none. Must be called with mut locked.
+func (s *Scheduler) arm(id JobID, j *job, after time.Time) {
+	j.next = j.schedule.Next(after)
+	if j.next.IsZero() {
+		logging.Debugf("Job %s has no more runs scheduled", j.name)
+		delete(s.jobs, id)
+		return
+	}
+
+	logging.Debugf("Next run of %s at %s", j.name, j.next)
+	j.timer = time.AfterFunc(time.Until(j.next), func() { s.fire(id, j) })
+}
+
+func (s *Scheduler) fire(id JobID, j *job) {
+	s.mut.Lock()
+	if s.jobs[id] != j {
+		s.mut.Unlock()
+		return // Cancelled after the timer fired
+	}
+	s.mut.Unlock()
+
+	logging.Infof("Running scheduled job %s", j.name)
+	j.run()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.jobs[id] == j {
+		s.arm(id, j, j.next)
+	}
+}
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneShot(t *testing.T) {
+	assert := assert.New(t)
+	at := date(time.March, 5, 11, 0)
+
+	assert.Equal(at, OneShot(at).Next(at.Add(-time.Minute)))
+	assert.Zero(OneShot(at).Next(at))
+}
+
+func TestScheduler(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+	var testSubject Scheduler
+
+	t.Run("One shot runs once", func(t *testing.T) {
+		ran := make(chan bool, 2)
+		id := testSubject.At("test", time.Now().Add(10*time.Millisecond), func() { ran <- true })
+
+		_, scheduled := testSubject.NextRun(id)
+		assert.True(scheduled)
+
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			assert.Fail("Job did not run")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		_, scheduled = testSubject.NextRun(id)
+		assert.False(scheduled)
+	})
+
+	t.Run("Cancelled job does not run", func(t *testing.T) {
+		ran := make(chan bool, 1)
+		id := testSubject.At("test", time.Now().Add(20*time.Millisecond), func() { ran <- true })
+		testSubject.Cancel(id)
+
+		select {
+		case <-ran:
+			assert.Fail("Cancelled job ran")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		testSubject.Cancel(id) // Cancelling twice is harmless
+	})
+
+	t.Run("Past one shot is dropped", func(t *testing.T) {
+		id := testSubject.At("test", time.Now().Add(-time.Minute), func() {})
+		_, scheduled := testSubject.NextRun(id)
+		assert.False(scheduled)
+	})
+}
@@ -0,0 +1,93 @@
+package templates
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const EVETimeFormat string = "Mon _2 Jan 15:04" // Format used for all times shown to users
+const day time.Duration = time.Hour * 24
+const dotlanURL string = "https://evemaps.dotlan.net"
+
+// Helper functions available to every template
+var helperFuncs = template.FuncMap{
+	"duration":   formatDuration,
+	"until":      time.Until,
+	"evetime":    formatEVETime,
+	"percent":    formatPercent,
+	"dotlan":     DotlanSystemLink,
+	"dotlanmap":  DotlanRegionLink,
+	"despawn":    despawnString,
+	"prediction": FormatPrediction,
+}
+
+// Formats a duration as days, hours and minutes, e.g. 1d2h3m. Duration.String only goes up to hours.
+func formatDuration(duration time.Duration) string {
+	var result string
+
+	if duration < 0 {
+		duration = 0
+	}
+
+	if duration >= day {
+		result += fmt.Sprintf("%dd", int(duration.Hours()/24))
+		duration = duration % day
+	}
+
+	result += fmt.Sprintf("%dh", int(duration.Hours()))
+	duration = duration % time.Hour
+
+	result += fmt.Sprintf("%dm", int(duration.Minutes()))
+	return result
+}
+
+// Formats the time as EVE time (UTC)
+func formatEVETime(t time.Time) string {
+	if t.IsZero() {
+		return "Unknown"
+	}
+
+	return t.UTC().Format(EVETimeFormat)
+}
+
+// Converts a 0 to 1 ratio into a percentage string
+func formatPercent(ratio float64) string {
+	return fmt.Sprintf("%.2f%%", ratio*100)
+}
+
+// Creates a dotlan link for the given system name
+func DotlanSystemLink(name string) string {
+	return fmt.Sprintf("%s/system/%s", dotlanURL, dotlanName(name))
+}
+
+// Creates a dotlan link for the given region name
+func DotlanRegionLink(name string) string {
+	return fmt.Sprintf("%s/map/%s", dotlanURL, dotlanName(name))
+}
+
+// Dotlan uses underscores in place of spaces
+func dotlanName(name string) string {
+	return url.PathEscape(strings.ReplaceAll(name, " ", "_"))
+}
+
+func despawnString(incursion incursions.Incursion) string {
+	return strings.TrimSpace(incursion.TimeLeftString(EVETimeFormat))
+}
+
+// Describes a spawn prediction relative to now
+func FormatPrediction(prediction incursions.SpawnPrediction) string {
+	switch prediction.Kind {
+	case incursions.ExactPrediction:
+		return formatDuration(time.Until(prediction.Earliest))
+	case incursions.UpperBoundPrediction:
+		return fmt.Sprintf("No more than %s", formatDuration(time.Until(prediction.Earliest)))
+	case incursions.InWindowPrediction:
+		return fmt.Sprintf("Currently in a spawn window for another %s", formatDuration(time.Until(prediction.Latest)))
+	}
+
+	return "Unknown"
+}
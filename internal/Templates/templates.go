@@ -0,0 +1,162 @@
+package templates
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Names of the templates used to build outgoing messages
+const (
+	IncursionName  = "incursion"     // Short description of a single incursion, replaces Incursion.ToString in messages
+	NewIncursion   = "newIncursion"  // Notification for a newly spawned incursion
+	StateChange    = "stateChange"   // Notification for an incursion changing state
+	Despawn        = "despawn"       // Notification for a despawned incursion
+	IncursionEntry = "incursionRow"  // Single incursion in the incursions command and summaries
+	Digest         = "digest"        // Notifications held during quiet hours
+	DailySummary   = "dailySummary"  // Scheduled summary of the current incursions
+	WeeklySummary  = "weeklySummary" // Scheduled summary of the past week's activity
+	Reminder       = "reminder"      // Reminder that an incursion or respawn window is about to change
+	Subject        = "subject"       // Compact status shown as a chat room's subject
+	Presence       = "presence"      // Status message shown in the bot's presence
+	FeedTitle      = "feedTitle"     // Title of an event in the Atom and RSS feeds
+)
+
+// Built-in templates, used for any template not overridden by the chat backend or the config
+var defaultTemplates = map[string]string{
+	IncursionName: `{{.Layout.StagingSystem.Name}} {{with .SovOwner}}[{{.}}] {{end}}{{printf "{%.2f}" .SecStatus}} (HQ: {{.Layout.HQSystem.Name}}) ({{.Constellation.Name}} - {{.Region.Name}})`,
+	NewIncursion: `{{if .Home}}:siren: New incursion detected in a home region! {{template "incursion" .Incursion}} - {{.Incursion.Distance}} jumps :siren:` +
+		`{{else}}New incursion detected in {{template "incursion" .Incursion}} - {{.Incursion.Distance}} jumps{{end}}`,
+	StateChange:    `Incursion in {{template "incursion" .}} changed state to {{.State}}`,
+	Despawn:        `Incursion in {{template "incursion" .}} despawned`,
+	IncursionEntry: `{{template "incursion" .}} - Influence: {{percent .Influence}} - Status: {{.State}} - {{.Distance}} jumps, Despawn: {{despawn .}}`,
+	Digest: `Updates held during quiet hours:
+{{range .}}[{{evetime .Time}}] {{.Message}}
+{{end}}`,
+	DailySummary: `Incursion summary for {{evetime .Time}}:
+{{range .Incursions}}{{template "incursionRow" .}}
+{{else}}No active incursions
+{{end}}{{range .NextSpawns}}
+Next {{.Security}}sec spawn window: {{prediction .}}{{end}}`,
+	Reminder: `{{if eq .Kind "mobilizingEnding"}}Mobilizing ends in {{duration .Lead}} ({{evetime .At}}) for {{template "incursion" .Incursion}}` +
+		`{{else if eq .Kind "despawnSoon"}}Withdrawing spawn {{template "incursion" .Incursion}} despawns in {{duration .Lead}} ({{evetime .At}})` +
+		`{{else if eq .Kind "respawnWindowOpen"}}{{.Incursion.Security}}sec respawn window opens now` +
+		`{{else if eq .Kind "respawnWindowClosing"}}{{.Incursion.Security}}sec respawn window closes in {{duration .Lead}} ({{evetime .At}}){{end}}`,
+	Subject: `{{range $i, $inc := .Incursions}}{{if $i}} | {{end}}{{$inc.Security}}: {{$inc.Layout.StagingSystem.Name}} ({{$inc.Region.Name}}) {{$inc.State}}` +
+		`{{else}}No incursions{{end}} | Next null: {{prediction .NextNull}}, low: {{prediction .NextLow}}`,
+	Presence: `{{if .Stale}}ESI unreachable, data may be out of date; {{else if .FailingEndpoints}}ESI degraded; {{end}}{{.Null}} null, {{.Low}} low` +
+		`{{range .Home}}; home spawn in {{.Region.Name}}: {{.State}} {{percent .Influence}}{{end}}`,
+	FeedTitle: `{{if eq .Kind "spawn"}}New {{.Incursion.Security}}sec incursion in {{.Incursion.Constellation.Name}} ({{.Incursion.Region.Name}})` +
+		`{{else if eq .Kind "stateChange"}}{{.Incursion.Constellation.Name}} ({{.Incursion.Region.Name}}) is now {{.Incursion.State}}` +
+		`{{else if eq .Kind "despawn"}}{{.Incursion.Constellation.Name}} ({{.Incursion.Region.Name}}) despawned{{end}}`,
+	WeeklySummary: `Weekly incursion summary since {{evetime .Since}}:
+Spawns seen: {{len .Spawns}}
+Despawns: {{.Despawns}}
+Time spent established: {{duration .EstablishedTime}}
+Home region spawns: {{len .HomeSpawns}}{{range .HomeSpawns}}
+  {{template "incursion" .}}{{end}}`,
+}
+
+// Templates that are no longer used, and what took their place
+var removedTemplates = map[string]string{
+	"incursionList": `the incursions command is now a list of "incursionRow" entries`,
+}
+
+// Describes any overrides that won't be used as their author expects. Removed templates are never rendered, and names
+// that aren't built in or referenced from the config can only be used from other templates.
+func CheckOverrides(overrides map[string]string, referenced []string) []string {
+	var warnings []string
+	for name := range overrides {
+		if replacement, removed := removedTemplates[name]; removed {
+			warnings = append(warnings, fmt.Sprintf("template %s is no longer used, %s", name, replacement))
+		} else if _, builtIn := defaultTemplates[name]; !builtIn && !slices.Contains(referenced, name) {
+			warnings = append(warnings, fmt.Sprintf("template %s isn't a built-in template, it is only used if another template includes it", name))
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
+// Data passed to the new incursion template
+type NewIncursionData struct {
+	Incursion incursions.Incursion // The incursion that spawned
+	Home      bool                 // True if the incursion is in a home region
+}
+
+// Single entry in the quiet hours digest
+type DigestEntry struct {
+	Time    time.Time // Time the event happened
+	Message string    // Notification that would have been sent
+}
+
+// Data passed to the daily summary template
+type DailySummaryData struct {
+	Time       time.Time                    // Time the summary was made
+	Incursions incursions.IncursionList     // Current incursions
+	NextSpawns []incursions.SpawnPrediction // Next spawn window for each security class
+}
+
+// Data passed to the room subject template
+type SubjectData struct {
+	Incursions incursions.IncursionList   // Current incursions, nullsec first
+	NextNull   incursions.SpawnPrediction // Next nullsec spawn window
+	NextLow    incursions.SpawnPrediction // Next lowsec spawn window
+}
+
+// Data passed to the presence template
+type PresenceData struct {
+	Null  int                      // Number of nullsec incursions
+	Low   int                      // Number of lowsec incursions
+	Home  incursions.IncursionList // Incursions in home regions
+	Stale bool                     // True if ESI is unreachable or the data is out of date
+
+	FailingEndpoints []string // ESI endpoints the bot has stopped calling for now because they keep failing
+}
+
+// Renders outgoing messages from a set of named templates. Safe for concurrent use.
+type Renderer struct {
+	templates *template.Template
+}
+
+// Creates a renderer from the built-in templates. Each set of overrides is applied in order,
+// so later sets take precedence over earlier ones.
+func NewRenderer(overrides ...map[string]string) (*Renderer, error) {
+	sources := make(map[string]string)
+	for name, text := range defaultTemplates {
+		sources[name] = text
+	}
+
+	for _, overrideSet := range overrides {
+		for name, text := range overrideSet {
+			sources[name] = text
+		}
+	}
+
+	root := template.New("").Funcs(helperFuncs)
+	for name, text := range sources {
+		_, err := root.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+	}
+
+	return &Renderer{templates: root}, nil
+}
+
+// Reports whether a template with the given name exists
+func (r *Renderer) Has(name string) bool {
+	return r.templates.Lookup(name) != nil
+}
+
+// Renders the named template with the given data
+func (r *Renderer) Render(name string, data any) (string, error) {
+	var result strings.Builder
+
+	err := r.templates.ExecuteTemplate(&result, name, data)
+	return result.String(), err
+}
@@ -0,0 +1,209 @@
+package templates
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	notifications "IncursionBot/internal/Notifications"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testIncursion() incursions.Incursion {
+	return incursions.Incursion{
+		Constellation: incursions.NamedItem{Name: "Kalevala Expanse"},
+		Region:        incursions.NamedItem{Name: "Delve"},
+		Layout: incursions.IncursionLayout{
+			StagingSystem: incursions.NamedItem{Name: "1DQ1-A"},
+			HQSystem:      incursions.NamedItem{Name: "T5ZI-S"},
+		},
+		SovOwner:  "CONDI",
+		SecStatus: -0.38,
+		Influence: 0.5,
+		State:     incursions.Established,
+		Distance:  3,
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	assert := assert.New(t)
+	renderer, err := NewRenderer()
+	assert.NoError(err)
+
+	inc := testIncursion()
+
+	result, err := renderer.Render(IncursionName, inc)
+	assert.NoError(err)
+	assert.Equal("1DQ1-A [CONDI] {-0.38} (HQ: T5ZI-S) (Kalevala Expanse - Delve)", result)
+
+	result, err = renderer.Render(NewIncursion, NewIncursionData{Incursion: inc, Home: true})
+	assert.NoError(err)
+	assert.Contains(result, "home region")
+	assert.Contains(result, "3 jumps")
+
+	result, err = renderer.Render(StateChange, inc)
+	assert.NoError(err)
+	assert.Contains(result, "changed state to established")
+
+	result, err = renderer.Render(IncursionEntry, inc)
+	assert.NoError(err)
+	assert.Contains(result, "Influence: 50.00%")
+	assert.Contains(result, "Despawn: Unknown")
+
+	result, err = renderer.Render(Digest, []DigestEntry{{Time: time.Date(2024, time.March, 5, 3, 0, 0, 0, time.UTC), Message: "held"}})
+	assert.NoError(err)
+	assert.Contains(result, "[Tue  5 Mar 03:00] held")
+}
+
+func TestOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := map[string]string{Despawn: "backend", StateChange: "backend"}
+	config := map[string]string{Despawn: "config {{.Distance}}"}
+	renderer, err := NewRenderer(backend, config)
+	assert.NoError(err)
+
+	result, err := renderer.Render(Despawn, testIncursion())
+	assert.NoError(err)
+	assert.Equal("config 3", result)
+
+	result, err = renderer.Render(StateChange, testIncursion())
+	assert.NoError(err)
+	assert.Equal("backend", result)
+
+	assert.True(renderer.Has(Subject))
+	assert.False(renderer.Has("shortSubject"))
+
+	_, err = NewRenderer(map[string]string{Despawn: "{{.Broken"})
+	assert.Error(err)
+}
+
+func TestCheckOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Empty(CheckOverrides(map[string]string{Despawn: "", IncursionEntry: ""}, nil))
+
+	warnings := CheckOverrides(map[string]string{"incursionList": "", "myPartial": "", Despawn: ""}, nil)
+	if assert.Len(warnings, 2) {
+		assert.Contains(warnings[0], "incursionList is no longer used")
+		assert.Contains(warnings[1], "myPartial isn't a built-in template")
+	}
+
+	// Templates named in the config are used directly
+	assert.Empty(CheckOverrides(map[string]string{"shortSubject": ""}, []string{"shortSubject"}))
+}
+
+func TestHelpers(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("1d2h3m", formatDuration(26*time.Hour+3*time.Minute))
+	assert.Equal("1d0h0m", formatDuration(24*time.Hour))
+	assert.Equal("0h0m", formatDuration(-time.Hour))
+	assert.Equal("12.34%", formatPercent(0.1234))
+	assert.Equal("https://evemaps.dotlan.net/system/1DQ1-A", DotlanSystemLink("1DQ1-A"))
+	assert.Equal("https://evemaps.dotlan.net/map/Period_Basis", DotlanRegionLink("Period Basis"))
+	assert.Equal("Unknown", formatEVETime(time.Time{}))
+}
+
+func TestFormatPrediction(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+
+	assert.Equal("Unknown", FormatPrediction(incursions.SpawnPrediction{}))
+	assert.Equal("1d2h0m", FormatPrediction(incursions.SpawnPrediction{
+		Kind:     incursions.ExactPrediction,
+		Earliest: now.Add(26*time.Hour + time.Minute),
+	}))
+	assert.Equal("No more than 2h0m", FormatPrediction(incursions.SpawnPrediction{
+		Kind:     incursions.UpperBoundPrediction,
+		Earliest: now.Add(2*time.Hour + time.Minute),
+	}))
+	assert.Equal("Currently in a spawn window for another 3h0m", FormatPrediction(incursions.SpawnPrediction{
+		Kind:     incursions.InWindowPrediction,
+		Earliest: now.Add(-time.Hour),
+		Latest:   now.Add(3*time.Hour + time.Minute),
+	}))
+}
+
+func TestSummaryTemplates(t *testing.T) {
+	assert := assert.New(t)
+	renderer, _ := NewRenderer()
+
+	nextSpawns := []incursions.SpawnPrediction{
+		{Security: incursions.NullSec, Kind: incursions.UnknownPrediction},
+		{Security: incursions.LowSec, Kind: incursions.ExactPrediction, Earliest: time.Now().Add(time.Hour + time.Minute)},
+	}
+	result, err := renderer.Render(DailySummary, DailySummaryData{NextSpawns: nextSpawns})
+	assert.NoError(err)
+	assert.Contains(result, "No active incursions")
+	assert.Contains(result, "Next Nullsec spawn window: Unknown\nNext Lowsec spawn window: 1h0m")
+
+	result, err = renderer.Render(WeeklySummary, notifications.ActivityReport{
+		HomeSpawns:      incursions.IncursionList{testIncursion()},
+		EstablishedTime: 26 * time.Hour,
+	})
+	assert.NoError(err)
+	assert.Contains(result, "Time spent established: 1d2h0m")
+	assert.Contains(result, "Home region spawns: 1")
+	assert.Contains(result, "1DQ1-A")
+}
+
+func TestSubjectTemplate(t *testing.T) {
+	assert := assert.New(t)
+	renderer, _ := NewRenderer()
+
+	null := testIncursion()
+	null.Security = incursions.NullSec
+	low := testIncursion()
+	low.Security = incursions.LowSec
+	low.Layout.StagingSystem.Name = "Amamake"
+	low.Region.Name = "Heimatar"
+	low.State = incursions.Mobilizing
+
+	nextNull := incursions.SpawnPrediction{Kind: incursions.ExactPrediction, Earliest: time.Now().Add(time.Hour + time.Minute)}
+	nextLow := incursions.SpawnPrediction{Kind: incursions.UnknownPrediction}
+
+	result, err := renderer.Render(Subject, SubjectData{Incursions: incursions.IncursionList{null, low}, NextNull: nextNull, NextLow: nextLow})
+	assert.NoError(err)
+	assert.Equal("Null: 1DQ1-A (Delve) established | Low: Amamake (Heimatar) mobilizing | Next null: 1h0m, low: Unknown", result)
+
+	result, err = renderer.Render(Subject, SubjectData{NextNull: nextNull, NextLow: nextNull})
+	assert.NoError(err)
+	assert.Equal("No incursions | Next null: 1h0m, low: 1h0m", result)
+}
+
+func TestPresenceTemplate(t *testing.T) {
+	assert := assert.New(t)
+	renderer, _ := NewRenderer()
+
+	home := testIncursion()
+	home.Influence = 0.63
+
+	result, err := renderer.Render(Presence, PresenceData{Null: 2, Low: 1, Home: incursions.IncursionList{home}})
+	assert.NoError(err)
+	assert.Equal("2 null, 1 low; home spawn in Delve: established 63.00%", result)
+
+	result, err = renderer.Render(Presence, PresenceData{Stale: true})
+	assert.NoError(err)
+	assert.Equal("ESI unreachable, data may be out of date; 0 null, 0 low", result)
+
+	result, err = renderer.Render(Presence, PresenceData{Null: 1, FailingEndpoints: []string{"GET /universe/systems/{id}/"}})
+	assert.NoError(err)
+	assert.Equal("ESI degraded; 1 null, 0 low", result)
+}
+
+func TestFeedTitleTemplate(t *testing.T) {
+	assert := assert.New(t)
+	renderer, _ := NewRenderer()
+
+	inc := testIncursion()
+	inc.Security = incursions.NullSec
+
+	result, err := renderer.Render(FeedTitle, notifications.NewEvent(notifications.SpawnEvent, inc, false))
+	assert.NoError(err)
+	assert.Equal("New Nullsec incursion in Kalevala Expanse (Delve)", result)
+
+	result, err = renderer.Render(FeedTitle, notifications.NewEvent(notifications.StateChangeEvent, inc, false))
+	assert.NoError(err)
+	assert.Equal("Kalevala Expanse (Delve) is now established", result)
+}
@@ -0,0 +1,222 @@
+package universe
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Files in the JSON Lines Static Data Export holding the universe, one object per line
+const (
+	sdeRegions        string = "mapRegions.jsonl"
+	sdeConstellations string = "mapConstellations.jsonl"
+	sdeSystems        string = "mapSolarSystems.jsonl"
+	sdeStargates      string = "mapStargates.jsonl"
+	sdeInfo           string = "_sde.jsonl" // Build number and release date of the export
+)
+
+// Names are localised in the SDE, older exports have a plain string instead
+type sdeName string
+
+func (name *sdeName) UnmarshalJSON(data []byte) error {
+	var localised map[string]string
+	if json.Unmarshal(data, &localised) == nil {
+		*name = sdeName(localised["en"])
+		return nil
+	}
+
+	return json.Unmarshal(data, (*string)(name))
+}
+
+type sdeRegion struct {
+	ID             int     `json:"_key"`
+	Name           sdeName `json:"name"`
+	Constellations []int   `json:"constellationIDs"`
+}
+
+type sdeConstellation struct {
+	ID       int     `json:"_key"`
+	Name     sdeName `json:"name"`
+	RegionID int     `json:"regionID"`
+	Systems  []int   `json:"solarSystemIDs"`
+}
+
+type sdeSystem struct {
+	ID              int      `json:"_key"`
+	Name            sdeName  `json:"name"`
+	ConstellationID int      `json:"constellationID"`
+	RegionID        int      `json:"regionID"`
+	SecStatus       float64  `json:"securityStatus"`
+	Position        Position `json:"position"`
+}
+
+type sdeStargate struct {
+	ID          int `json:"_key"`
+	SystemID    int `json:"solarSystemID"`
+	Destination struct {
+		GateID   int `json:"stargateID"`
+		SystemID int `json:"solarSystemID"`
+	} `json:"destination"`
+}
+
+type sdeBuild struct {
+	Key         string      `json:"_key"`
+	BuildNumber json.Number `json:"buildNumber"`
+}
+
+// Builds the known space universe from the JSON Lines Static Data Export, either the zip or the directory it was
+// extracted to
+func ImportSDE(sdePath string) (*Universe, error) {
+	files, closeSDE, err := openSDE(sdePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSDE()
+
+	return importSDE(files)
+}
+
+// Gets the build number of the Static Data Export, empty if it doesn't say
+func SDEVersion(sdePath string) (string, error) {
+	files, closeSDE, err := openSDE(sdePath)
+	if err != nil {
+		return "", err
+	}
+	defer closeSDE()
+
+	return sdeVersion(files), nil
+}
+
+// Opens the zip or the directory it was extracted to
+func openSDE(sdePath string) (fs.FS, func() error, error) {
+	info, err := os.Stat(sdePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		return os.DirFS(sdePath), func() error { return nil }, nil
+	}
+
+	archive, err := zip.OpenReader(sdePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return archive, archive.Close, nil
+}
+
+func sdeVersion(files fs.FS) string {
+	var version string
+	readSDE(files, sdeInfo, func(info sdeBuild) { // Older exports don't have it
+		if info.Key == "sde" {
+			version = info.BuildNumber.String()
+		}
+	})
+	return version
+}
+
+func importSDE(files fs.FS) (*Universe, error) {
+	universe := New(SourceSDE)
+	universe.Version = sdeVersion(files)
+
+	err := readSDE(files, sdeRegions, func(region sdeRegion) {
+		if KnownSpace(region.ID) {
+			universe.Regions[region.ID] = &Region{ID: region.ID, Name: string(region.Name), Constellations: region.Constellations}
+		}
+	})
+	if err == nil {
+		err = readSDE(files, sdeConstellations, func(constellation sdeConstellation) {
+			if KnownSpace(constellation.RegionID) {
+				universe.Constellations[constellation.ID] = &Constellation{
+					ID:       constellation.ID,
+					Name:     string(constellation.Name),
+					RegionID: constellation.RegionID,
+					Systems:  constellation.Systems,
+				}
+			}
+		})
+	}
+	if err == nil {
+		err = readSDE(files, sdeSystems, func(system sdeSystem) {
+			if KnownSpace(system.RegionID) {
+				universe.Systems[system.ID] = &System{
+					ID:              system.ID,
+					Name:            string(system.Name),
+					ConstellationID: system.ConstellationID,
+					RegionID:        system.RegionID,
+					SecStatus:       system.SecStatus,
+					Position:        system.Position,
+				}
+			}
+		})
+	}
+	if err == nil {
+		err = readSDE(files, sdeStargates, func(gate sdeStargate) {
+			if system, pres := universe.Systems[gate.SystemID]; pres {
+				system.Stargates = append(system.Stargates, Stargate{
+					ID:                  gate.ID,
+					DestinationID:       gate.Destination.GateID,
+					DestinationSystemID: gate.Destination.SystemID,
+				})
+			}
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(universe.Systems) == 0 {
+		return nil, fmt.Errorf("no systems found in the SDE")
+	}
+
+	universe.Prune()
+	return universe, nil
+}
+
+// Parses every line of the named SDE file, wherever it is in the export
+func readSDE[T any](files fs.FS, name string, add func(T)) error {
+	var file string
+	err := fs.WalkDir(files, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && path.Base(filePath) == name {
+			file = filePath
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		return fmt.Errorf("%s not found in the SDE", name)
+	}
+
+	reader, err := files.Open(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1024*1024) // Some lines hold a lot of translations
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var item T
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return fmt.Errorf("%s line %d: %w", name, line, err)
+		}
+		add(item)
+	}
+
+	return scanner.Err()
+}
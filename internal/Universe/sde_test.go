@@ -0,0 +1,96 @@
+package universe
+
+import (
+	"archive/zip"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSDE = map[string]string{
+	sdeRegions: `{"_key": 10000002, "name": {"en": "The Forge", "de": "The Forge"}, "constellationIDs": [20000020]}
+{"_key": 11000001, "name": {"en": "A-R00001"}, "constellationIDs": [21000001]}
+`,
+	sdeConstellations: `{"_key": 20000020, "name": {"en": "Kimotoro"}, "regionID": 10000002, "solarSystemIDs": [30000142, 30000144]}
+{"_key": 21000001, "name": {"en": "A-C00311"}, "regionID": 11000001, "solarSystemIDs": [31000005]}`,
+	sdeSystems: `{"_key": 30000142, "name": {"en": "Jita"}, "constellationID": 20000020, "regionID": 10000002, "securityStatus": 0.9459, "position": {"x": 1, "y": 2, "z": 3}}
+{"_key": 30000144, "name": "Perimeter", "constellationID": 20000020, "regionID": 10000002, "securityStatus": 0.9072}
+{"_key": 31000005, "name": {"en": "J055520"}, "constellationID": 21000001, "regionID": 11000001, "securityStatus": -0.99}`,
+	sdeInfo: `{"_key": "sde", "buildNumber": 3064089, "releaseDate": "2025-10-28T11:14:02Z"}`,
+	sdeStargates: `{"_key": 50001248, "solarSystemID": 30000142, "destination": {"solarSystemID": 30000144, "stargateID": 50001249}}
+{"_key": 50001249, "solarSystemID": 30000144, "destination": {"solarSystemID": 30000142, "stargateID": 50001248}}
+{"_key": 50001250, "solarSystemID": 30000144, "destination": {"solarSystemID": 30000145, "stargateID": 50001251}}`,
+}
+
+func TestImportSDE(t *testing.T) {
+	assert := assert.New(t)
+
+	files := fstest.MapFS{}
+	for name, content := range testSDE {
+		files["sde/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+
+	universe, err := importSDE(files)
+	assert.NoError(err)
+	assert.Equal(SourceSDE, universe.Source)
+	assert.Equal("3064089", universe.Version)
+
+	// Wormhole space is left out
+	assert.Len(universe.Regions, 1)
+	assert.Len(universe.Constellations, 1)
+	assert.Len(universe.Systems, 2)
+
+	jita := universe.Systems[30000142]
+	assert.Equal("Jita", jita.Name)
+	assert.Equal(10000002, jita.RegionID)
+	assert.Equal(0.9459, jita.SecStatus)
+	assert.Equal(Position{X: 1, Y: 2, Z: 3}, jita.Position)
+	assert.Equal([]Stargate{{ID: 50001248, DestinationID: 50001249, DestinationSystemID: 30000144}}, jita.Stargates)
+
+	name, _ := universe.Name(30000144)
+	assert.Equal("Perimeter", name)
+	assert.Len(universe.Systems[30000144].Stargates, 1) // The gate to a system that isn't in the export is dropped
+	assert.Equal([]int{30000144, 30000142}, universe.Route(30000144, 30000142))
+
+	t.Run("Zip", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "sde.zip")
+		out, err := os.Create(file)
+		assert.NoError(err)
+
+		archive := zip.NewWriter(out)
+		for name, content := range testSDE {
+			writer, err := archive.Create(name)
+			assert.NoError(err)
+			writer.Write([]byte(content))
+		}
+		assert.NoError(archive.Close())
+		assert.NoError(out.Close())
+
+		universe, err := ImportSDE(file)
+		assert.NoError(err)
+		assert.Len(universe.Systems, 2)
+
+		version, err := SDEVersion(file)
+		assert.NoError(err)
+		assert.Equal("3064089", version)
+	})
+
+	t.Run("Without a version", func(t *testing.T) {
+		files := maps.Clone(files)
+		delete(files, "sde/"+sdeInfo)
+
+		universe, err := importSDE(files)
+		assert.NoError(err)
+		assert.Empty(universe.Version)
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		delete(files, "sde/"+sdeStargates)
+		_, err := importSDE(files)
+		assert.ErrorContains(err, sdeStargates)
+	})
+}
@@ -0,0 +1,229 @@
+package universe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const wormholeRegions int = 11000000 // Regions from here up are wormhole, abyssal and other unreachable space
+
+// Where a universe was built from
+type Source string
+
+const (
+	SourceESI Source = "esi"
+	SourceSDE Source = "sde" // The EVE Static Data Export
+)
+
+// Coordinates of a system in metres
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// A stargate and the gate it jumps to
+type Stargate struct {
+	ID                  int `json:"id"`
+	DestinationID       int `json:"destinationId"`
+	DestinationSystemID int `json:"destinationSystemId"`
+}
+
+// A solar system and its stargates
+type System struct {
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	ConstellationID int        `json:"constellationId"`
+	RegionID        int        `json:"regionId"`
+	SecStatus       float64    `json:"secStatus"` // Unrounded, -1 to 1 inclusive
+	Position        Position   `json:"position"`
+	Stargates       []Stargate `json:"stargates"`
+}
+
+type Constellation struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	RegionID int    `json:"regionId"`
+	Systems  []int  `json:"systems"`
+}
+
+type Region struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Constellations []int  `json:"constellations"`
+}
+
+// Every system, constellation and region along with the stargates between them. Read-only once built, so it can be
+// shared freely.
+type Universe struct {
+	Source         Source                 `json:"source"`
+	Version        string                 `json:"version,omitempty"` // SDE build number or Tranquility server version it was built from
+	BuiltAt        time.Time              `json:"builtAt"`
+	Systems        map[int]*System        `json:"systems"`
+	Constellations map[int]*Constellation `json:"constellations"`
+	Regions        map[int]*Region        `json:"regions"`
+}
+
+// Counts of what a universe contains
+type Summary struct {
+	Source         Source    `json:"source"`
+	Version        string    `json:"version,omitempty"`
+	BuiltAt        time.Time `json:"builtAt"`
+	Systems        int       `json:"systems"`
+	Stargates      int       `json:"stargates"`
+	Constellations int       `json:"constellations"`
+	Regions        int       `json:"regions"`
+}
+
+func New(source Source) *Universe {
+	return &Universe{
+		Source:         source,
+		BuiltAt:        time.Now(),
+		Systems:        make(map[int]*System),
+		Constellations: make(map[int]*Constellation),
+		Regions:        make(map[int]*Region),
+	}
+}
+
+// Loads a universe saved with Save
+func Load(file string) (*Universe, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	universe := New("")
+	err = json.Unmarshal(data, universe)
+	if err != nil {
+		return nil, err
+	}
+
+	return universe, nil
+}
+
+// Saves the universe to the file, replacing it in one step so a crash never leaves it half written
+func (u *Universe) Save(file string) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+// Checks if a region is in known space, rather than wormhole, abyssal or other space without stargates
+func KnownSpace(regionID int) bool {
+	return regionID < wormholeRegions
+}
+
+// Drops stargates leading to systems the universe doesn't have, so routes never leave it
+func (u *Universe) Prune() {
+	for _, system := range u.Systems {
+		gates := system.Stargates[:0]
+		for _, gate := range system.Stargates {
+			if _, pres := u.Systems[gate.DestinationSystemID]; pres {
+				gates = append(gates, gate)
+			}
+		}
+		system.Stargates = gates
+	}
+}
+
+// Gets the name of a system, constellation or region
+func (u *Universe) Name(id int) (string, bool) {
+	if system, pres := u.Systems[id]; pres {
+		return system.Name, true
+	}
+	if constellation, pres := u.Constellations[id]; pres {
+		return constellation.Name, true
+	}
+	if region, pres := u.Regions[id]; pres {
+		return region.Name, true
+	}
+	return "", false
+}
+
+// Gets the systems one jump away from the system
+func (u *Universe) Neighbours(systemID int) []int {
+	system, pres := u.Systems[systemID]
+	if !pres {
+		return nil
+	}
+
+	var result []int
+	for _, gate := range system.Stargates {
+		result = append(result, gate.DestinationSystemID)
+	}
+	return result
+}
+
+// Finds the shortest route between two systems, including both ends like ESI's /route/. Nil if there is no route.
+//
+// This is a plain breadth-first search over every stargate, while ESI's /route/ also applies travel rules that aren't part
+// of the gate graph. Routes through Zarzakh, whose gates only let a ship leave by the gate it arrived through, can come out
+// shorter here than ESI's. Pochven's gates only link its own systems, so like ESI there is no route between it and the rest
+// of known space.
+func (u *Universe) Route(from int, to int) []int {
+	if _, pres := u.Systems[from]; !pres {
+		return nil
+	}
+	if _, pres := u.Systems[to]; !pres {
+		return nil
+	}
+
+	previous := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 && queue[0] != to {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range u.Neighbours(current) {
+			if _, seen := previous[next]; !seen {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if _, reached := previous[to]; !reached {
+		return nil
+	}
+
+	route := []int{to}
+	for route[0] != from {
+		route = append([]int{previous[route[0]]}, route...)
+	}
+	return route
+}
+
+// Counts what the universe contains
+func (u *Universe) Summary() Summary {
+	summary := Summary{
+		Source:         u.Source,
+		Version:        u.Version,
+		BuiltAt:        u.BuiltAt,
+		Systems:        len(u.Systems),
+		Constellations: len(u.Constellations),
+		Regions:        len(u.Regions),
+	}
+	for _, system := range u.Systems {
+		summary.Stargates += len(system.Stargates)
+	}
+	return summary
+}
@@ -0,0 +1,75 @@
+package universe
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A chain of systems 1 - 2 - 3 - 4 with a shortcut from 1 to 3, and a gate out of the universe from 4
+func testUniverse() *Universe {
+	universe := New(SourceESI)
+	universe.Regions[10] = &Region{ID: 10, Name: "Region", Constellations: []int{20}}
+	universe.Constellations[20] = &Constellation{ID: 20, Name: "Constellation", RegionID: 10, Systems: []int{1, 2, 3, 4}}
+
+	links := map[int][]int{1: {2, 3}, 2: {1, 3}, 3: {1, 2, 4}, 4: {3, 99}}
+	for id, destinations := range links {
+		system := &System{ID: id, Name: string(rune('A' + id - 1)), ConstellationID: 20, RegionID: 10}
+		for _, destination := range destinations {
+			system.Stargates = append(system.Stargates, Stargate{ID: id*100 + destination, DestinationID: destination*100 + id, DestinationSystemID: destination})
+		}
+		universe.Systems[id] = system
+	}
+
+	universe.Prune()
+	return universe
+}
+
+func TestUniverse(t *testing.T) {
+	assert := assert.New(t)
+	universe := testUniverse()
+
+	assert.Equal([]int{1, 3, 4}, universe.Route(1, 4))
+	assert.Equal([]int{2}, universe.Route(2, 2))
+	assert.Nil(universe.Route(1, 99))
+
+	assert.ElementsMatch([]int{2, 3}, universe.Neighbours(1))
+	assert.Equal([]int{3}, universe.Neighbours(4)) // The gate out of the universe is dropped
+
+	for id, expected := range map[int]string{1: "A", 20: "Constellation", 10: "Region"} {
+		name, pres := universe.Name(id)
+		assert.True(pres)
+		assert.Equal(expected, name)
+	}
+	_, pres := universe.Name(99)
+	assert.False(pres)
+
+	summary := universe.Summary()
+	assert.Equal(4, summary.Systems)
+	assert.Equal(8, summary.Stargates)
+
+	t.Run("Saved", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "universe.json")
+		assert.NoError(universe.Save(file))
+
+		loaded, err := Load(file)
+		assert.NoError(err)
+		assert.Equal(universe.Summary().Stargates, loaded.Summary().Stargates)
+		assert.Equal([]int{1, 3, 4}, loaded.Route(1, 4))
+		assert.Equal(SourceESI, loaded.Source)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "universe.json"))
+		assert.Error(err)
+	})
+}
+
+func TestKnownSpace(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(KnownSpace(10000002))  // The Forge
+	assert.False(KnownSpace(11000001)) // Wormhole space
+	assert.False(KnownSpace(12000001)) // Abyssal space
+}
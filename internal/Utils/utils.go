@@ -1,7 +1,9 @@
 package Utils
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/mattn/go-xmpp"
 )
@@ -29,3 +31,16 @@ func Cleanup(channel chan<- xmpp.Chat, logger *log.Logger) {
 
 	close(channel)
 }
+
+// Sleeps for the duration, returning early with the context's error if it is cancelled
+func SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const icalTimeFormat string = "20060102T150405Z"
+const icalLineLimit int = 75 // Longest a content line may be, in octets, before it must be folded
+
+// A single entry in the calendar. Entries with no end are points in time.
+type CalendarEvent struct {
+	UID         string // Stable for the thing being predicted, so calendars update the entry as the prediction changes
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Updated     time.Time // When the prediction last changed
+}
+
+// Serves predicted incursion timings as an iCalendar (RFC 5545) feed
+type Calendar struct {
+	Name   string
+	Events func() []CalendarEvent
+}
+
+func (c *Calendar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="incursions.ics"`)
+	w.Write([]byte(c.render(time.Now())))
+}
+
+func (c *Calendar) render(now time.Time) string {
+	var out strings.Builder
+	line := func(name string, value string) {
+		writeICalLine(&out, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Incursion Bot//Incursion timings//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeICal(c.Name))
+	line("REFRESH-INTERVAL;VALUE=DURATION", "PT15M")
+	line("X-PUBLISHED-TTL", "PT15M")
+
+	for _, event := range c.Events() {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", now.UTC().Format(icalTimeFormat))
+		if !event.Updated.IsZero() {
+			line("LAST-MODIFIED", event.Updated.UTC().Format(icalTimeFormat))
+		}
+		line("DTSTART", event.Start.UTC().Format(icalTimeFormat))
+		if event.End.After(event.Start) {
+			line("DTEND", event.End.UTC().Format(icalTimeFormat))
+		} else {
+			line("DTEND", event.Start.UTC().Format(icalTimeFormat))
+		}
+		line("SUMMARY", escapeICal(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeICal(event.Description))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return out.String()
+}
+
+// Escapes text values as required by RFC 5545
+func escapeICal(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// Writes a content line, folding it onto continuation lines if it is too long. Folds never split a UTF-8 character.
+func writeICalLine(out *strings.Builder, text string) {
+	limit := icalLineLimit
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(text[cut]) {
+			cut--
+		}
+
+		out.WriteString(text[:cut])
+		out.WriteString("\r\n ")
+		text = text[cut:]
+		limit = icalLineLimit - 1 // Continuation lines start with a space
+	}
+
+	out.WriteString(text)
+	out.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
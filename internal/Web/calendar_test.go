@@ -0,0 +1,62 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	calendar := Calendar{
+		Name: "Incursions",
+		Events: func() []CalendarEvent {
+			return []CalendarEvent{
+				{UID: "despawn-1@test", Summary: "Despawn, NLT", Start: start},
+				{UID: "window-1@test", Summary: "Window", Description: "Line one\nLine two", Start: start, End: start.Add(time.Hour * 24)},
+			}
+		},
+	}
+
+	result := calendar.render(start)
+	assert.True(strings.HasPrefix(result, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(strings.HasSuffix(result, "END:VCALENDAR\r\n"))
+	assert.Equal(2, strings.Count(result, "BEGIN:VEVENT\r\n"))
+	assert.Contains(result, "SUMMARY:Despawn\\, NLT\r\n")
+	assert.Contains(result, "DTSTART:20220301T120000Z\r\nDTEND:20220301T120000Z\r\n")
+	assert.Contains(result, "DTEND:20220302T120000Z\r\n")
+	assert.Contains(result, "DESCRIPTION:Line one\\nLine two\r\n")
+
+	t.Run("Served", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		calendar.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/calendar.ics", nil))
+
+		assert.Contains(recorder.Header().Get("Content-Type"), "text/calendar")
+		assert.Contains(recorder.Body.String(), "UID:window-1@test\r\n")
+	})
+}
+
+func TestICalFolding(t *testing.T) {
+	assert := assert.New(t)
+	var out strings.Builder
+
+	writeICalLine(&out, "SUMMARY:"+strings.Repeat("a", 70)+"éé"+strings.Repeat("b", 80))
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n")
+
+	assert.Len(lines, 3)
+	for i, line := range lines {
+		assert.LessOrEqual(len(line), icalLineLimit)
+		if i > 0 {
+			assert.True(strings.HasPrefix(line, " "))
+		}
+	}
+
+	unfolded := strings.ReplaceAll(strings.TrimSuffix(out.String(), "\r\n"), "\r\n ", "")
+	assert.Equal("SUMMARY:"+strings.Repeat("a", 70)+"éé"+strings.Repeat("b", 80), unfolded)
+}
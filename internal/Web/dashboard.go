@@ -0,0 +1,48 @@
+package web
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Serves the dashboard pages and their assets. Everything is embedded so no external resources are needed.
+func Dashboard() http.Handler {
+	files, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err) // The directory is embedded, this can't happen
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(files)))
+	mux.HandleFunc("GET /{$}", page(files, "index.html"))
+	mux.HandleFunc("GET /history", page(files, "history.html"))
+	return mux
+}
+
+func page(files fs.FS, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, files, name)
+	}
+}
+
+// Creates a handler serving whatever the given function returns as JSON
+func JSONHandler(get func() any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := json.Marshal(get())
+		if err != nil {
+			logging.Errorf("Failed to encode response to %s: %v", r.URL.Path, err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(data)
+	}
+}
@@ -0,0 +1,40 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboard(t *testing.T) {
+	assert := assert.New(t)
+	handler := Dashboard()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	index := get("/")
+	assert.Equal(http.StatusOK, index.Code)
+	assert.Contains(index.Body.String(), "/static/dashboard.js")
+
+	assert.Contains(get("/history").Body.String(), "/static/history.js")
+	assert.Equal(http.StatusOK, get("/static/style.css").Code)
+	assert.Equal(http.StatusNotFound, get("/missing").Code)
+}
+
+func TestJSONHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := httptest.NewRecorder()
+	JSONHandler(func() any { return map[string]int{"a": 1} })(recorder, httptest.NewRequest(http.MethodGet, "/api", nil))
+
+	body, _ := io.ReadAll(recorder.Body)
+	assert.Equal("application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(`{"a": 1}`, string(body))
+}
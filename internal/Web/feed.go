@@ -0,0 +1,159 @@
+package web
+
+import (
+	notifications "IncursionBot/internal/Notifications"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const feedHistorySize int = 256 // Events kept for clients resuming after a disconnect
+const feedClientBuffer int = 64 // Messages queued for a client before it is dropped as too slow
+
+// Type of a snapshot message, all other messages are typed by their event kind
+const SnapshotMessage string = "snapshot"
+
+// A message sent to live feed clients
+type FeedMessage struct {
+	ID   string `json:"id"`   // Start of the bot and sequence number of the event, or of the last event included in a snapshot
+	Type string `json:"type"` // Either snapshot or the kind of event
+	Data any    `json:"data"`
+	seq  uint64
+}
+
+// Keeps live clients up to date with incursion events, numbering each event so clients can resume after disconnecting.
+// Event IDs start with the time the feed was created, as numbering starts over whenever the bot restarts.
+type Feed struct {
+	mut          sync.Mutex
+	epoch        string
+	seq          uint64
+	snapshotID   uint64 // Last event included in the snapshot
+	snapshotData any
+	history      []FeedMessage
+	clients      map[chan FeedMessage]struct{}
+}
+
+// Replaces the full state sent to clients that are new or can't be caught up from history. It should include
+// every event sent so far.
+func (feed *Feed) SetSnapshot(data any) {
+	feed.mut.Lock()
+	defer feed.mut.Unlock()
+	feed.start()
+
+	feed.snapshotID = feed.seq
+	feed.snapshotData = data
+}
+
+func (feed *Feed) Notify(event notifications.Event) {
+	if event.Kind == notifications.ReminderEvent {
+		return
+	}
+
+	feed.mut.Lock()
+	defer feed.mut.Unlock()
+	feed.start()
+
+	feed.seq++
+	msg := FeedMessage{
+		ID:   feed.messageID(feed.seq),
+		seq:  feed.seq,
+		Type: string(event.Kind),
+		Data: notifications.NewEventPayload(event),
+	}
+
+	feed.history = append(feed.history, msg)
+	if len(feed.history) > feedHistorySize {
+		feed.history = feed.history[len(feed.history)-feedHistorySize:]
+	}
+
+	for client := range feed.clients {
+		select {
+		case client <- msg:
+		default:
+			// Too far behind, it can resume from its last event when it reconnects
+			delete(feed.clients, client)
+			close(client)
+		}
+	}
+}
+
+// Registers a new client. A client that has seen events before passes the ID of the last one to resume from it,
+// otherwise it passes an empty ID and starts from a snapshot. Returns the messages to catch up with, a channel of new
+// messages that is closed if the client falls too far behind, and a function to unregister the client.
+func (feed *Feed) Subscribe(lastEventID string) ([]FeedMessage, <-chan FeedMessage, func()) {
+	feed.mut.Lock()
+	defer feed.mut.Unlock()
+	feed.start()
+
+	lastID, resume := feed.parseID(lastEventID)
+	var initial []FeedMessage
+	if !resume || !feed.canResume(lastID) {
+		// Start from the snapshot, followed by anything that happened since it was taken
+		initial = append(initial, FeedMessage{ID: feed.messageID(feed.snapshotID), Type: SnapshotMessage, Data: feed.snapshotData, seq: feed.snapshotID})
+		lastID = feed.snapshotID
+	}
+
+	for _, msg := range feed.history {
+		if msg.seq > lastID {
+			initial = append(initial, msg)
+		}
+	}
+
+	client := make(chan FeedMessage, feedClientBuffer)
+	if feed.clients == nil {
+		feed.clients = make(map[chan FeedMessage]struct{})
+	}
+	feed.clients[client] = struct{}{}
+
+	cancel := func() {
+		feed.mut.Lock()
+		defer feed.mut.Unlock()
+
+		if _, pres := feed.clients[client]; pres {
+			delete(feed.clients, client)
+			close(client)
+		}
+	}
+
+	return initial, client, cancel
+}
+
+// Checks if every event after the given ID is still in history. Must be called with mut locked.
+func (feed *Feed) canResume(lastID uint64) bool {
+	if lastID > feed.seq {
+		return false
+	}
+
+	if len(feed.history) == 0 {
+		return lastID == feed.seq
+	}
+
+	return lastID+1 >= feed.history[0].seq
+}
+
+// Must be called with mut locked
+func (feed *Feed) start() {
+	if feed.epoch == "" {
+		feed.epoch = strconv.FormatInt(time.Now().Unix(), 10)
+	}
+}
+
+func (feed *Feed) messageID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", feed.epoch, seq)
+}
+
+// Gets the sequence number from an event ID, false if it's missing or from before the bot last restarted
+func (feed *Feed) parseID(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != feed.epoch {
+		return 0, false
+	}
+
+	result, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return result, true
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	notifications "IncursionBot/internal/Notifications"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func messageIDs(messages []FeedMessage) []uint64 {
+	var ids []uint64
+	for _, msg := range messages {
+		ids = append(ids, msg.seq)
+	}
+	return ids
+}
+
+func TestFeed(t *testing.T) {
+	assert := assert.New(t)
+	var feed Feed
+
+	event := notifications.NewEvent(notifications.SpawnEvent, incursions.Incursion{}, false)
+	feed.Notify(event)
+	feed.Notify(event)
+	feed.SetSnapshot("two events")
+	feed.Notify(event)
+
+	t.Run("New clients start from the snapshot", func(t *testing.T) {
+		initial, _, cancel := feed.Subscribe("")
+		defer cancel()
+
+		assert.Equal([]uint64{2, 3}, messageIDs(initial))
+		assert.Equal(SnapshotMessage, initial[0].Type)
+		assert.Equal("two events", initial[0].Data)
+		assert.Equal("spawn", initial[1].Type)
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		initial, _, cancel := feed.Subscribe(feed.messageID(1))
+		defer cancel()
+
+		assert.Equal([]uint64{2, 3}, messageIDs(initial))
+		assert.Equal("spawn", initial[0].Type)
+
+		initial, _, cancel = feed.Subscribe(feed.messageID(3))
+		defer cancel()
+		assert.Empty(initial)
+	})
+
+	t.Run("Resume from before a restart", func(t *testing.T) {
+		// Numbering starts over, so an ID from before isn't resumed from even though it's in range now
+		initial, _, cancel := feed.Subscribe("1000-1")
+		defer cancel()
+		assert.Equal(SnapshotMessage, initial[0].Type)
+		assert.Equal(feed.messageID(2), initial[0].ID)
+
+		initial, _, cancel = feed.Subscribe(feed.messageID(10))
+		defer cancel()
+		assert.Equal(SnapshotMessage, initial[0].Type)
+
+		initial, _, cancel = feed.Subscribe("3")
+		defer cancel()
+		assert.Equal(SnapshotMessage, initial[0].Type)
+	})
+
+	t.Run("Live updates", func(t *testing.T) {
+		_, updates, cancel := feed.Subscribe(feed.messageID(3))
+
+		feed.Notify(notifications.NewEvent(notifications.ReminderEvent, incursions.Incursion{}, false))
+		feed.Notify(notifications.NewEvent(notifications.DespawnEvent, incursions.Incursion{}, false))
+
+		msg := <-updates
+		assert.Equal(feed.messageID(4), msg.ID)
+		assert.Equal("despawn", msg.Type)
+
+		cancel()
+		_, open := <-updates
+		assert.False(open)
+		cancel()
+	})
+}
+
+func TestFeedHistoryLimit(t *testing.T) {
+	assert := assert.New(t)
+	var feed Feed
+
+	event := notifications.NewEvent(notifications.SpawnEvent, incursions.Incursion{}, false)
+	for i := 0; i < feedHistorySize+10; i++ {
+		feed.Notify(event)
+	}
+
+	initial, _, cancel := feed.Subscribe(feed.messageID(5))
+	defer cancel()
+	assert.Equal(SnapshotMessage, initial[0].Type)
+
+	initial, _, cancel = feed.Subscribe(feed.messageID(10))
+	defer cancel()
+	assert.Len(initial, feedHistorySize)
+}
+
+func TestFeedDropsSlowClients(t *testing.T) {
+	assert := assert.New(t)
+	var feed Feed
+
+	_, updates, cancel := feed.Subscribe("")
+	defer cancel()
+
+	event := notifications.NewEvent(notifications.SpawnEvent, incursions.Incursion{}, false)
+	for i := 0; i < feedClientBuffer+1; i++ {
+		feed.Notify(event)
+	}
+
+	count := 0
+	for range updates {
+		count++
+	}
+	assert.Equal(feedClientBuffer, count)
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const keepAliveInterval time.Duration = time.Second * 30 // Time between messages sent to keep idle connections open
+
+// Streams the feed as Server-Sent Events. Clients resume with the standard Last-Event-ID header, or the lastEventId
+// query parameter.
+func (feed *Feed) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	initial, updates, cancel := feed.Subscribe(lastEventID(r))
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, msg := range initial {
+		if writeSSE(w, msg) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case msg, open := <-updates:
+			if !open {
+				logging.Warningf("Dropping slow event stream client %s", r.RemoteAddr)
+				return
+			}
+			if writeSSE(w, msg) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeSSE(w http.ResponseWriter, msg FeedMessage) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		logging.Errorf("Failed to encode %s feed message: %v", msg.Type, err)
+		return nil // Skip it rather than dropping the client
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+	return err
+}
+
+// Gets the ID of the last event a reconnecting client saw, empty if it didn't send one
+func lastEventID(r *http.Request) string {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+
+	return value
+}
@@ -0,0 +1,80 @@
+package web
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Reads the lines of the next event from the stream
+func readSSE(reader *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return lines
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestServeSSE(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	var feed Feed
+	feed.SetSnapshot([]string{"current"})
+	feed.Notify(notifications.NewEvent(notifications.SpawnEvent, incursions.Incursion{}, false))
+
+	server := httptest.NewServer(http.HandlerFunc(feed.ServeSSE))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(err)
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal([]string{"id: " + feed.messageID(0), "event: snapshot", `data: ["current"]`}, readSSE(reader))
+
+	spawn := readSSE(reader)
+	assert.Equal([]string{"id: " + feed.messageID(1), "event: spawn"}, spawn[:2])
+
+	feed.Notify(notifications.NewEvent(notifications.DespawnEvent, incursions.Incursion{}, false))
+	assert.Equal([]string{"id: " + feed.messageID(2), "event: despawn"}, readSSE(reader)[:2])
+
+	t.Run("Resume", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Last-Event-ID", feed.messageID(1))
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(err)
+		defer resp.Body.Close()
+
+		assert.Equal([]string{"id: " + feed.messageID(2), "event: despawn"}, readSSE(bufio.NewReader(resp.Body))[:2])
+	})
+}
+
+func TestLastEventID(t *testing.T) {
+	assert := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/events?lastEventId=1700000000-12", nil)
+	assert.Equal("1700000000-12", lastEventID(req))
+
+	req.Header.Set("Last-Event-ID", "1700000000-14")
+	assert.Equal("1700000000-14", lastEventID(req))
+
+	assert.Empty(lastEventID(httptest.NewRequest(http.MethodGet, "/events", nil)))
+}
@@ -0,0 +1,44 @@
+"use strict";
+
+// Formats a date as EVE time
+function eveTime(value) {
+	if (!value) {
+		return "Unknown";
+	}
+	return new Date(value).toISOString().slice(0, 16).replace("T", " ");
+}
+
+// Formats a number of milliseconds as days, hours and minutes
+function duration(ms) {
+	if (ms <= 0) {
+		return "0m";
+	}
+	const minutes = Math.floor(ms / 60000);
+	const days = Math.floor(minutes / 1440);
+	const hours = Math.floor((minutes % 1440) / 60);
+	const parts = [];
+	if (days > 0) parts.push(days + "d");
+	if (hours > 0) parts.push(hours + "h");
+	parts.push((minutes % 60) + "m");
+	return parts.join(" ");
+}
+
+function percent(value) {
+	return (value * 100).toFixed(1) + "%";
+}
+
+// Links to a system on dotlan
+function dotlanLink(system) {
+	const link = document.createElement("a");
+	link.href = "https://evemaps.dotlan.net/system/" + encodeURIComponent(system.replaceAll(" ", "_"));
+	link.textContent = system;
+	return link;
+}
+
+// Creates an element with the given class and text
+function element(tag, className, text) {
+	const el = document.createElement(tag);
+	if (className) el.className = className;
+	if (text !== undefined) el.textContent = text;
+	return el;
+}
@@ -0,0 +1,114 @@
+"use strict";
+
+const refreshDelay = 1000; // Wait for a burst of events to finish before refreshing
+let refreshTimer = null;
+
+function despawnText(incursion) {
+	if (!incursion.despawn) {
+		return "Unknown";
+	}
+	const prefix = incursion.state === "established" ? "NLT " : "";
+	return prefix + eveTime(incursion.despawn) + " (" + duration(new Date(incursion.despawn) - Date.now()) + ")";
+}
+
+function layoutRow(label, systems) {
+	const row = element("div", "layout-row");
+	row.append(element("span", "label", label));
+	const list = element("span");
+	systems.filter(s => s).forEach((system, i) => {
+		if (i > 0) list.append(", ");
+		list.append(dotlanLink(system));
+	});
+	if (!list.hasChildNodes()) list.textContent = "Unknown";
+	row.append(list);
+	return row;
+}
+
+function incursionCard(incursion) {
+	const card = element("article", "incursion " + incursion.state + (incursion.home ? " home" : ""));
+
+	const title = element("h2");
+	title.append(dotlanLink(incursion.stagingSystem));
+	title.append(" " + incursion.constellation + " – " + incursion.region);
+	card.append(title);
+
+	const details = element("div", "details");
+	details.append(element("span", "security " + incursion.security.toLowerCase(), incursion.security + "sec " + incursion.secStatus.toFixed(2)));
+	details.append(element("span", "state", incursion.state || "unknown"));
+	details.append(element("span", "", incursion.distance + " jumps"));
+	if (incursion.sovOwner) details.append(element("span", "", "Sov: " + incursion.sovOwner));
+	if (incursion.home) details.append(element("span", "home-tag", "Home"));
+	card.append(details);
+
+	const influence = element("div", "influence");
+	const bar = element("div", "bar");
+	bar.style.width = percent(incursion.influence);
+	influence.append(bar, element("span", "", "Influence " + percent(incursion.influence)));
+	card.append(influence);
+
+	card.append(element("p", "despawn", "Despawn: " + despawnText(incursion)));
+
+	const layout = element("div", "layout");
+	layout.append(layoutRow("Staging", [incursion.stagingSystem]));
+	layout.append(layoutRow("Vanguard", incursion.vanguardSystems || []));
+	layout.append(layoutRow("Assault", incursion.assaultSystems || []));
+	layout.append(layoutRow("HQ", [incursion.hqSystem]));
+	card.append(layout);
+
+	return card;
+}
+
+function showPrediction(el, prediction) {
+	el.textContent = prediction.text;
+	el.title = prediction.earliest ? "Window " + eveTime(prediction.earliest) + " to " + eveTime(prediction.latest) + " EVE time" : "";
+}
+
+function render(snapshot) {
+	if (!snapshot) {
+		return; // No poll has finished yet
+	}
+	showPrediction(document.getElementById("next-null"), snapshot.nextNull);
+	showPrediction(document.getElementById("next-low"), snapshot.nextLow);
+
+	const list = document.getElementById("incursions");
+	list.replaceChildren(...snapshot.incursions.map(incursionCard));
+	document.getElementById("empty").hidden = snapshot.incursions.length > 0;
+}
+
+async function refresh() {
+	refreshTimer = null;
+	try {
+		const response = await fetch("/api/incursions");
+		render(await response.json());
+	} catch (err) {
+		console.error("Failed to refresh incursions", err);
+	}
+}
+
+function scheduleRefresh() {
+	if (refreshTimer === null) {
+		refreshTimer = setTimeout(refresh, refreshDelay);
+	}
+}
+
+function connect() {
+	const status = document.getElementById("status");
+	const events = new EventSource("/events");
+
+	events.onopen = () => {
+		status.textContent = "Live";
+		status.className = "status live";
+	};
+	events.onerror = () => {
+		status.textContent = "Reconnecting…";
+		status.className = "status";
+	};
+	events.addEventListener("snapshot", e => render(JSON.parse(e.data)));
+	for (const kind of ["spawn", "stateChange", "influence", "despawn"]) {
+		events.addEventListener(kind, scheduleRefresh);
+	}
+}
+
+connect();
+// Keep the despawn countdowns current
+setInterval(refresh, 60000);
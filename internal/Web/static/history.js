@@ -0,0 +1,41 @@
+"use strict";
+
+// Total time the spawn spent established
+function establishedTime(record) {
+	const end = record.despawned ? new Date(record.despawned) : new Date();
+	let total = 0;
+	record.states.forEach((state, i) => {
+		if (state.state !== "established") return;
+		const until = i + 1 < record.states.length ? new Date(record.states[i + 1].since) : end;
+		total += until - new Date(state.since);
+	});
+	return total;
+}
+
+function historyRow(record) {
+	const row = element("tr", record.home ? "home" : "");
+	const start = record.spawned || record.firstSeen;
+	const end = record.despawned ? new Date(record.despawned) : new Date();
+
+	const staging = element("td");
+	staging.append(dotlanLink(record.stagingSystem));
+	row.append(staging);
+	row.append(element("td", "", record.constellation + " – " + record.region));
+	row.append(element("td", "security " + record.security.toLowerCase(), record.security));
+	row.append(element("td", "", (record.spawned ? "" : "Before ") + eveTime(start)));
+	row.append(element("td", "", record.despawned ? eveTime(record.despawned) : "Active"));
+	row.append(element("td", "", duration(end - new Date(start))));
+	row.append(element("td", "", duration(establishedTime(record))));
+	row.append(element("td", "", percent(record.influence)));
+	return row;
+}
+
+async function load() {
+	const response = await fetch("/api/history");
+	const records = await response.json();
+
+	document.querySelector("#history tbody").replaceChildren(...records.map(historyRow));
+	document.getElementById("empty").hidden = records.length > 0;
+}
+
+load();
@@ -0,0 +1,291 @@
+package web
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const feedIDPrefix string = "tag:incursion-bot,2022:" // Prefix for the permanent IDs of feeds and entries
+const maxFeedEntries int = 50
+
+// A single event in the Atom and RSS feeds
+type SyndicationEntry struct {
+	ID       string // Stable for the event, the feed adds its own prefix
+	Title    string
+	Content  string
+	Link     string
+	Updated  time.Time
+	Security incursions.SecurityClass
+	Region   string
+	Home     bool
+}
+
+// Serves recent events as Atom and RSS feeds. Feeds are available for every event (/feeds/all.atom), a security class
+// (/feeds/null.atom, /feeds/low.atom), home regions (/feeds/home.atom) or a single region (/feeds/region/Delve.atom).
+// Replacing .atom with .rss gives an RSS 2.0 feed.
+type Syndication struct {
+	Title   string
+	Entries func() []SyndicationEntry // Recent events, most recent first
+}
+
+// Adds the feed routes to the mux
+func (s *Syndication) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /feeds/{feed}", s.serveFeed)
+	mux.HandleFunc("GET /feeds/region/{region}", s.serveRegionFeed)
+}
+
+func (s *Syndication) serveFeed(w http.ResponseWriter, r *http.Request) {
+	name, format, ok := splitFeedName(r.PathValue("feed"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var title string
+	var filter func(SyndicationEntry) bool
+	switch name {
+	case "all":
+		title = s.Title
+		filter = func(SyndicationEntry) bool { return true }
+	case "null":
+		title = s.Title + " - Nullsec"
+		filter = func(entry SyndicationEntry) bool { return entry.Security == incursions.NullSec }
+	case "low":
+		title = s.Title + " - Lowsec"
+		filter = func(entry SyndicationEntry) bool { return entry.Security == incursions.LowSec }
+	case "home":
+		title = s.Title + " - Home regions"
+		filter = func(entry SyndicationEntry) bool { return entry.Home }
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	s.serve(w, r, format, title, filter)
+}
+
+func (s *Syndication) serveRegionFeed(w http.ResponseWriter, r *http.Request) {
+	name, format, ok := splitFeedName(r.PathValue("region"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Accept the underscores dotlan uses in place of spaces
+	region := strings.ReplaceAll(name, "_", " ")
+	s.serve(w, r, format, s.Title+" - "+region, func(entry SyndicationEntry) bool {
+		return strings.EqualFold(entry.Region, region)
+	})
+}
+
+func (s *Syndication) serve(w http.ResponseWriter, r *http.Request, format string, title string, filter func(SyndicationEntry) bool) {
+	var entries []SyndicationEntry
+	for _, entry := range s.Entries() {
+		if filter(entry) {
+			entries = append(entries, entry)
+		}
+		if len(entries) == maxFeedEntries {
+			break
+		}
+	}
+
+	info := feedInfo{
+		ID:    feedIDPrefix + "feed" + r.URL.Path,
+		Title: title,
+		Self:  requestURL(r),
+		Home:  baseURL(r) + "/",
+	}
+
+	var doc any
+	var contentType string
+	if format == "atom" {
+		doc = newAtomFeed(info, entries)
+		contentType = "application/atom+xml; charset=utf-8"
+	} else {
+		doc = newRSSFeed(info, entries)
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		logging.Errorf("Failed to encode feed %s: %v", r.URL.Path, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+type feedInfo struct {
+	ID    string
+	Title string
+	Self  string // URL of the feed itself
+	Home  string // URL of the site the feed belongs to
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID       string         `xml:"id"`
+	Title    string         `xml:"title"`
+	Updated  string         `xml:"updated"`
+	Link     *atomLink      `xml:"link,omitempty"`
+	Content  atomContent    `xml:"content"`
+	Category []atomCategory `xml:"category"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func newAtomFeed(info feedInfo, entries []SyndicationEntry) atomFeed {
+	feed := atomFeed{
+		ID:      info.ID,
+		Title:   info.Title,
+		Updated: feedUpdated(entries).Format(time.RFC3339),
+		Author:  atomAuthor{Name: "Incursion Bot"},
+		Links:   []atomLink{{Rel: "self", Href: info.Self}, {Rel: "alternate", Href: info.Home}},
+	}
+
+	for _, entry := range entries {
+		atom := atomEntry{
+			ID:      feedIDPrefix + "event/" + entry.ID,
+			Title:   entry.Title,
+			Updated: entry.Updated.UTC().Format(time.RFC3339),
+			Content: atomContent{Type: "text", Text: entry.Content},
+		}
+		if entry.Link != "" {
+			atom.Link = &atomLink{Rel: "alternate", Href: entry.Link}
+		}
+		if entry.Security != "" {
+			atom.Category = append(atom.Category, atomCategory{Term: string(entry.Security)})
+		}
+		if entry.Region != "" {
+			atom.Category = append(atom.Category, atomCategory{Term: entry.Region})
+		}
+
+		feed.Entries = append(feed.Entries, atom)
+	}
+
+	return feed
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func newRSSFeed(info feedInfo, entries []SyndicationEntry) rssFeed {
+	channel := rssChannel{
+		Title:         info.Title,
+		Link:          info.Home,
+		Description:   info.Title,
+		LastBuildDate: feedUpdated(entries).Format(time.RFC1123Z),
+	}
+
+	for _, entry := range entries {
+		item := rssItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			Description: entry.Content,
+			GUID:        rssGUID{Value: feedIDPrefix + "event/" + entry.ID},
+			PubDate:     entry.Updated.UTC().Format(time.RFC1123Z),
+		}
+		if entry.Security != "" {
+			item.Categories = append(item.Categories, string(entry.Security))
+		}
+		if entry.Region != "" {
+			item.Categories = append(item.Categories, entry.Region)
+		}
+
+		channel.Items = append(channel.Items, item)
+	}
+
+	return rssFeed{Version: "2.0", Channel: channel}
+}
+
+// Feeds were last updated by their most recent entry. An empty feed uses the Unix epoch so it doesn't look new on every fetch.
+func feedUpdated(entries []SyndicationEntry) time.Time {
+	if len(entries) == 0 {
+		return time.Unix(0, 0).UTC()
+	}
+
+	return entries[0].Updated.UTC()
+}
+
+// Splits a feed file name such as null.atom into its name and format
+func splitFeedName(file string) (string, string, bool) {
+	for _, format := range []string{"atom", "rss"} {
+		name, found := strings.CutSuffix(file, "."+format)
+		if found && name != "" {
+			return name, format, true
+		}
+	}
+
+	return "", "", false
+}
+
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+func requestURL(r *http.Request) string {
+	return baseURL(r) + r.URL.EscapedPath()
+}
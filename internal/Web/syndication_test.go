@@ -0,0 +1,90 @@
+package web
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSyndication() *http.ServeMux {
+	updated := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	syndication := Syndication{
+		Title: "Incursions",
+		Entries: func() []SyndicationEntry {
+			return []SyndicationEntry{
+				{ID: "2", Title: "Low spawn", Updated: updated.Add(time.Hour), Security: incursions.LowSec, Region: "Black Rise"},
+				{ID: "1", Title: "Null spawn", Updated: updated, Security: incursions.NullSec, Region: "Delve", Home: true},
+			}
+		},
+	}
+
+	mux := http.NewServeMux()
+	syndication.Register(mux)
+	return mux
+}
+
+func getFeed(mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://bot.example"+path, nil))
+	return recorder
+}
+
+func TestAtomFeed(t *testing.T) {
+	assert := assert.New(t)
+	mux := testSyndication()
+
+	resp := getFeed(mux, "/feeds/all.atom")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Header().Get("Content-Type"), "application/atom+xml")
+
+	var feed atomFeed
+	assert.NoError(xml.Unmarshal(resp.Body.Bytes(), &feed))
+	assert.Equal("tag:incursion-bot,2022:feed/feeds/all.atom", feed.ID)
+	assert.Equal("2022-03-01T13:00:00Z", feed.Updated)
+	assert.Equal("http://bot.example/feeds/all.atom", feed.Links[0].Href)
+	assert.Len(feed.Entries, 2)
+	assert.Equal("tag:incursion-bot,2022:event/2", feed.Entries[0].ID)
+
+	t.Run("Filters", func(t *testing.T) {
+		for path, title := range map[string]string{
+			"/feeds/null.atom":              "Null spawn",
+			"/feeds/low.atom":               "Low spawn",
+			"/feeds/home.atom":              "Null spawn",
+			"/feeds/region/Black_Rise.atom": "Low spawn",
+			"/feeds/region/delve.atom":      "Null spawn",
+		} {
+			var feed atomFeed
+			assert.NoError(xml.Unmarshal(getFeed(mux, path).Body.Bytes(), &feed))
+			if assert.Len(feed.Entries, 1, path) {
+				assert.Equal(title, feed.Entries[0].Title, path)
+			}
+		}
+	})
+
+	t.Run("Unknown feeds", func(t *testing.T) {
+		assert.Equal(http.StatusNotFound, getFeed(mux, "/feeds/high.atom").Code)
+		assert.Equal(http.StatusNotFound, getFeed(mux, "/feeds/all.json").Code)
+		assert.Equal(http.StatusNotFound, getFeed(mux, "/feeds/region/.rss").Code)
+	})
+}
+
+func TestRSSFeed(t *testing.T) {
+	assert := assert.New(t)
+
+	resp := getFeed(testSyndication(), "/feeds/null.rss")
+	assert.Contains(resp.Header().Get("Content-Type"), "application/rss+xml")
+
+	var feed rssFeed
+	assert.NoError(xml.Unmarshal(resp.Body.Bytes(), &feed))
+	assert.Equal("2.0", feed.Version)
+	assert.Equal("http://bot.example/", feed.Channel.Link)
+	assert.Len(feed.Channel.Items, 1)
+	assert.Equal("tag:incursion-bot,2022:event/1", feed.Channel.Items[0].GUID.Value)
+	assert.False(feed.Channel.Items[0].GUID.IsPermaLink)
+	assert.Equal("Tue, 01 Mar 2022 12:00:00 +0000", feed.Channel.Items[0].PubDate)
+}
@@ -0,0 +1,255 @@
+package web
+
+import (
+	logging "IncursionBot/internal/Logging"
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const websocketGUID string = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11" // Fixed by RFC 6455 for computing the accept key
+const maxWebSocketFrame int = 4096                                  // Clients only send control frames, anything larger is refused
+
+// WebSocket frame opcodes
+const (
+	textFrame  byte = 0x1
+	closeFrame byte = 0x8
+	pingFrame  byte = 0x9
+	pongFrame  byte = 0xA
+)
+
+var errFrameTooLarge = errors.New("websocket frame too large")
+
+// Streams the feed over a WebSocket as JSON encoded FeedMessages. Clients resume with the lastEventId query parameter.
+func (feed *Feed) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		logging.Warningf("Failed to upgrade websocket from %s: %v", r.RemoteAddr, err)
+		return
+	}
+	defer conn.Close()
+
+	initial, updates, cancel := feed.Subscribe(lastEventID(r))
+	defer cancel()
+
+	closed := make(chan struct{})
+	go conn.readUntilClosed(closed)
+
+	for _, msg := range initial {
+		if conn.writeJSON(msg) != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case msg, open := <-updates:
+			if !open {
+				logging.Warningf("Dropping slow websocket client %s", r.RemoteAddr)
+				conn.writeFrame(closeFrame, closePayload(1008, "too slow"))
+				return
+			}
+			if conn.writeJSON(msg) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if conn.writeFrame(pingFrame, nil) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Server side of a WebSocket connection
+type wsConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	writeMut sync.Mutex
+}
+
+// Completes the opening handshake and takes over the connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "Expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Websockets not supported", http.StatusInternalServerError)
+		return nil, errors.New("connection can't be hijacked")
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, reader: buf.Reader}, nil
+}
+
+func (ws *wsConn) Close() error {
+	return ws.conn.Close()
+}
+
+func (ws *wsConn) writeJSON(msg FeedMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logging.Errorf("Failed to encode %s feed message: %v", msg.Type, err)
+		return nil // Skip it rather than dropping the client
+	}
+
+	return ws.writeFrame(textFrame, data)
+}
+
+// Writes a single unfragmented frame. Frames from the server are never masked.
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.writeMut.Lock()
+	defer ws.writeMut.Unlock()
+
+	header := []byte{0x80 | opcode}
+	length := len(payload)
+
+	switch {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	ws.conn.SetWriteDeadline(time.Now().Add(keepAliveInterval))
+	_, err := ws.conn.Write(append(header, payload...))
+	return err
+}
+
+// Reads a single frame, unmasking its payload
+func (ws *wsConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.reader, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.reader, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if length > uint64(maxWebSocketFrame) {
+		return 0, nil, errFrameTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return opcode, payload, nil
+}
+
+// Answers pings and waits for the client to close the connection, closing the channel once it has
+func (ws *wsConn) readUntilClosed(closed chan struct{}) {
+	defer close(closed)
+
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			if errors.Is(err, errFrameTooLarge) {
+				ws.writeFrame(closeFrame, closePayload(1009, "frame too large"))
+			}
+			return
+		}
+
+		switch opcode {
+		case pingFrame:
+			ws.writeFrame(pongFrame, payload)
+		case closeFrame:
+			ws.writeFrame(closeFrame, payload)
+			return
+		}
+	}
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func closePayload(code uint16, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, code), reason...)
+}
+
+// Checks if a comma separated header contains the given token, ignoring case
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
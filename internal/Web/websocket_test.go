@@ -0,0 +1,107 @@
+package web
+
+import (
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Opens a websocket to the test server, returning the client side of the connection
+func dialWebSocket(t *testing.T, url string) *wsConn {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn.Write([]byte("GET /ws HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Unexpected handshake response %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Wrong accept key %s", resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+
+	return &wsConn{conn: conn, reader: reader}
+}
+
+func readFeedMessage(t *testing.T, client *wsConn) FeedMessage {
+	opcode, payload, err := client.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opcode != textFrame {
+		t.Fatalf("Unexpected opcode %d", opcode)
+	}
+
+	var msg FeedMessage
+	json.Unmarshal(payload, &msg)
+	return msg
+}
+
+func TestServeWebSocket(t *testing.T) {
+	assert := assert.New(t)
+	logging.InitLogger(false)
+
+	var feed Feed
+	feed.SetSnapshot("current")
+
+	server := httptest.NewServer(http.HandlerFunc(feed.ServeWebSocket))
+	defer server.Close()
+
+	client := dialWebSocket(t, server.URL)
+	defer client.Close()
+
+	snapshot := readFeedMessage(t, client)
+	assert.Equal(SnapshotMessage, snapshot.Type)
+	assert.Equal("current", snapshot.Data)
+
+	feed.Notify(notifications.NewEvent(notifications.SpawnEvent, incursions.Incursion{}, false))
+	spawn := readFeedMessage(t, client)
+	assert.Equal(feed.messageID(1), spawn.ID)
+	assert.Equal("spawn", spawn.Type)
+
+	t.Run("Ping", func(t *testing.T) {
+		client.writeFrame(pingFrame, []byte("hello"))
+
+		opcode, payload, err := client.readFrame()
+		assert.NoError(err)
+		assert.Equal(pongFrame, opcode)
+		assert.Equal("hello", string(payload))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		client.writeFrame(closeFrame, closePayload(1000, ""))
+
+		opcode, _, err := client.readFrame()
+		assert.NoError(err)
+		assert.Equal(closeFrame, opcode)
+	})
+}
+
+func TestWebSocketRejectsPlainRequests(t *testing.T) {
+	var feed Feed
+	recorder := httptest.NewRecorder()
+
+	feed.ServeWebSocket(recorder, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
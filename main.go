@@ -2,27 +2,43 @@ package main
 
 import (
 	Chat "IncursionBot/internal/ChatClient"
+	console "IncursionBot/internal/ChatClient/ConsoleClient"
 	jabber "IncursionBot/internal/ChatClient/JabberClient"
 	"IncursionBot/internal/ESI"
 	incursions "IncursionBot/internal/Incursions"
 	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	scheduler "IncursionBot/internal/Scheduler"
+	templates "IncursionBot/internal/Templates"
 	"bufio"
+	"context"
 	"flag"
-	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
 const homeSystem int = 30004759 // 1DQ1-A
 const commandPrefix byte = '!'  // All commands must start with this prefix
-const timeFormat string = "Mon _2 Jan 15:04"
+
+const commandTimeout time.Duration = time.Second * 30 // Longest a command may spend waiting on ESI
+const pollTimeout time.Duration = time.Minute * 5     // Longest a single poll of ESI, including building layouts, may take
 
 var commandsMap CommandMap                 // Map of all supported commands, their functions, and their help messages
 var startTime time.Time                    // Time the bot was started
 var incManager incursions.IncursionManager // Manages known incursions and informs on state changes
 var esi ESI.ESIClient
+var renderer *templates.Renderer           // Renders all outgoing messages
+var notifier notifications.Dispatcher      // Sends incursion events to everyone interested in them
+var activity notifications.ActivityTracker // Records recent events for the weekly summary and feeds
+var sched scheduler.Scheduler              // Runs scheduled jobs such as summary posts
+var dryRun bool                            // Nothing is sent to real channels or services when set
+var roomSubjects []*roomSubject            // Rooms whose subject shows the current incursions
+var background sync.WaitGroup              // Work that must finish before the bot exits, such as polls and saves
 
 // Returns goon home regions (currently Delve, Querious, and Period Basis)
 func getHomeRegions() IDList {
@@ -41,35 +57,49 @@ func (list IDList) contains(val int) bool {
 	return false
 }
 
-func mainLoop() {
+// Processes polled incursions until the context is cancelled
+func mainLoop(ctx context.Context) {
 	incursionUpdateChan := make(chan incursions.IncursionList)
 	firstRun := true
-	go pollESI(incursionUpdateChan)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		pollESI(ctx, incursionUpdateChan)
+	}()
 
 	for {
-		newUpdates := <-incursionUpdateChan
+		var newUpdates incursions.IncursionList
+		select {
+		case newUpdates = <-incursionUpdateChan:
+		case <-ctx.Done():
+			return
+		}
 
+		layoutCtx, cancel := context.WithTimeout(ctx, pollTimeout)
 		if firstRun {
-			incManager.PopulateIncursions(newUpdates, &esi)
+			incManager.PopulateIncursions(layoutCtx, newUpdates, &esi)
 		} else {
-			incManager.ProcessIncursions(newUpdates, &esi)
+			incManager.ProcessIncursions(layoutCtx, newUpdates, &esi)
 		}
+		cancel()
 
 		firstRun = false
 	}
 }
 
-// Creates a notification message for a new incursion, creating a special message if the incursion is in a home region
-func getNewIncursionMsg(newIncursion incursions.Incursion) string {
-	if getHomeRegions().contains(newIncursion.Region.ID) {
-		return fmt.Sprintf(":siren: New incursion detected in a home region! %s - %d jumps :siren:", newIncursion.ToString(), newIncursion.Distance)
+// Renders the named message template, logging and returning an empty message on failure
+func renderMessage(name string, data any) string {
+	msgText, err := renderer.Render(name, data)
+	if err != nil {
+		logging.Errorf("Failed to render the %s template: %v", name, err)
+		return ""
 	}
 
-	return fmt.Sprintf("New incursion detected in %s - %d jumps", newIncursion.ToString(), newIncursion.Distance)
+	return msgText
 }
 
 // Polls jabber and processes any commands received
-func pollChat(jabber Chat.ChatServer) {
+func pollChat(ctx context.Context, jabber Chat.ChatServer) {
 	for {
 		msg, err := jabber.GetNextChatMessage()
 
@@ -91,7 +121,9 @@ func pollChat(jabber Chat.ChatServer) {
 			continue
 		}
 
-		jabber.ReplyToMsg(function(msg), msg)
+		commandCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+		jabber.ReplyToMsg(function(commandCtx, msg), msg)
+		cancel()
 	}
 }
 
@@ -127,10 +159,12 @@ func init() {
 	commandsMap = NewCommandMap()
 	commandsMap.AddCommand("incursions", listIncursions, "Lists the current incursions")
 	commandsMap.AddCommand("uptime", getUptime, "Gets the current bot uptime")
-	//	commandsMap.AddCommand("esi", printESIStatus, "Prints the bot's ESI connection status")   REMOVED UNTIL IMPLEMENTED
+	commandsMap.AddCommand("esi", printESIStatus, "Prints the bot's ESI connection status")
 	commandsMap.AddCommand("nextspawn", nextSpawn, "Lists the start of the next spawn window for null and low incursions")
 	commandsMap.AddCommand("waitlist", waitlistInstructions, "Explains how to join the manual waitlist while the waitlist site is down")
 	commandsMap.AddCommand("layout", printLayout, "Prints the calculated layout of the given spawn")
+	commandsMap.AddForm("layout", layoutForm)
+	commandsMap.AddForm("incursions", incursionsForm)
 }
 
 func main() {
@@ -143,42 +177,176 @@ func main() {
 	jabberServer := flag.String("server", "conference.goonfleet.com", "Jabber server to connect to")
 	jabberChannel := flag.String("chat", "testbot", "MUC to join on start")
 	botNick := flag.String("nickname", "IncursionBot", "Name bot will connect to MUC with")
+	configFile := flag.String("config", "", "JSON file containing additional bot configuration")
+	useConsole := flag.Bool("console", false, "Reads commands from stdin and prints messages to stdout instead of connecting to Jabber")
+	flag.BoolVar(&dryRun, "dry-run", false, "Prints outgoing messages instead of sending them")
 	flag.Parse()
 
 	logging.InitLogger(*debug)
-	esi = ESI.NewClient()
 
 	if *userFile != "" {
 		userName, password = parseFile(*userFile)
 	}
 
-	if *userName == "" || *password == "" {
+	if !*useConsole && (*userName == "" || *password == "") {
 		log.Fatalln("One or more required parameters was missing")
 	}
 
-	client, err := jabber.CreateNewJabberConnection(*jabberServer, *jabberChannel, *userName, *password, *botNick)
+	config, err := loadConfig(*configFile)
+	if err != nil {
+		log.Fatalf("Failed to load config file %s: %s", *configFile, err)
+	}
+
+	var esiOptions []ESI.ClientOption
+	if config.ESIBaseURL != "" {
+		esiOptions = append(esiOptions, ESI.WithBaseURL(config.ESIBaseURL))
+	}
+	if config.ESIUserAgent != "" {
+		esiOptions = append(esiOptions, ESI.WithUserAgent(config.ESIUserAgent))
+	}
+	esi = ESI.NewClient(esiOptions...)
+
+	if config.ESICacheDir != "" {
+		err = esi.UseDiskCache(config.ESICacheDir)
+		if err != nil {
+			log.Fatalln("Failed to set up the ESI cache directory: ", err)
+		}
+	}
+
+	var client Chat.ChatServer
+	if *useConsole {
+		client = console.NewConsole(os.Stdin, os.Stdout)
+	} else {
+		client, err = jabber.CreateNewJabberConnection(*jabberServer, *jabberChannel, *userName, *password, *botNick)
+		if err != nil {
+			log.Fatalln("Failed initial connection to the server: ", err)
+		}
+	}
+
+	if dryRun {
+		logging.Infoln("Dry run enabled, outgoing messages will only be printed")
+		client = console.NewDryRun(client, os.Stdout)
+	}
+
+	var overrides map[string]string
+	if overrider, ok := client.(Chat.TemplateOverrider); ok {
+		overrides = overrider.TemplateOverrides()
+	}
+
+	renderer, err = templates.NewRenderer(overrides, config.Templates)
+	if err != nil {
+		log.Fatalln("Failed to load message templates: ", err)
+	}
+	var referenced []string
+	for _, destination := range config.Destinations {
+		referenced = append(referenced, destination.Subject.Template)
+	}
+	for _, warning := range append(templates.CheckOverrides(overrides, referenced), templates.CheckOverrides(config.Templates, referenced)...) {
+		logging.Warningln("Message templates:", warning)
+	}
+
+	destinations, err := createDestinations(config.Destinations, *jabberChannel, client)
+	if err != nil {
+		log.Fatalln("Failed to set up notification destinations: ", err)
+	}
+
+	webhooks, err := createWebhooks(config.Webhooks)
 	if err != nil {
-		log.Fatalln("Failed initial connection to the server: ", err)
+		log.Fatalln("Failed to set up webhooks: ", err)
 	}
 
+	subscriptions = notifications.Subscriptions{
+		File:        config.SubscriptionsFile,
+		Send:        func(user string, message string) error { return client.SendToUser(message, user) },
+		FormatEvent: formatEvent,
+	}
+	err = subscriptions.Load()
+	if err != nil {
+		log.Fatalln("Failed to load subscriptions: ", err)
+	}
+
+	history.File = config.HistoryFile
+	err = history.Load()
+	if err != nil {
+		log.Fatalln("Failed to load spawn history: ", err)
+	}
+
+	activity.File = config.ActivityFile
+	err = activity.Load()
+	if err != nil {
+		log.Fatalln("Failed to load recent activity: ", err)
+	}
+
+	notifier.AddSink(&activity)
+	notifier.AddSink(&history)
+	notifier.AddSink(&subscriptions)
+	for _, dest := range destinations {
+		notifier.AddSink(dest)
+	}
+	for _, hook := range webhooks {
+		notifier.AddSink(hook)
+	}
+	notifier.AddSink(&liveFeed)
+
 	incManager = incursions.IncursionManager{
+		Reminders: &incursions.Reminders{
+			Scheduler: &sched,
+			OnReminder: func(r incursions.Reminder) {
+				logging.Infof("Sending %s reminder", r.Kind)
+				notifier.Notify(notifications.NewReminderEvent(r, getHomeRegions().contains(r.Incursion.Region.ID)))
+			},
+		},
 		OnNewIncursion: func(i incursions.Incursion) {
-			msgText := getNewIncursionMsg(i)
 			logging.Infoln("Sending new incursion notification to chat")
-			client.BroadcastToDefaultChannel(msgText)
+			notifier.Notify(newEvent(notifications.SpawnEvent, i))
 		},
 		OnIncursionUpdate: func(i incursions.Incursion) {
-			msgText := fmt.Sprintf("Incursion in %s changed state to %s", i.ToString(), i.State)
 			logging.Infoln("Sending state change notification to chat")
-			client.BroadcastToDefaultChannel(msgText)
+			notifier.Notify(newEvent(notifications.StateChangeEvent, i))
 		},
 		OnIncursionDespawn: func(i incursions.Incursion) {
-			msgText := fmt.Sprintf("Incursion in %s despawned", i.ToString())
 			logging.Infof("Sending despawn notification for %s", i.ToString())
-			client.BroadcastToDefaultChannel(msgText)
+			notifier.Notify(newEvent(notifications.DespawnEvent, i))
+		},
+		InfluenceThresholds: config.InfluenceThresholds,
+		OnInfluenceThreshold: func(i incursions.Incursion, threshold float64) {
+			logging.Infof("Influence of %s crossed %.0f%%", i.ToString(), threshold*100)
+			notifier.Notify(notifications.NewInfluenceEvent(i, getHomeRegions().contains(i.Region.ID), threshold))
 		},
+		OnListChanged: func(list incursions.IncursionList) {
+			history.Observe(list, time.Now())
+			liveFeed.SetSnapshot(newFeedSnapshot(list))
+			for _, subject := range roomSubjects {
+				subject.Update(list)
+			}
+			updatePresence()
+		},
+	}
+
+	// Cancelled on shutdown, stopping any ESI requests in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	setupHealthChecks(ctx)
+	universeMaxAge := defaultUniverseMaxAge
+	if config.UniverseMaxAge != "" {
+		universeMaxAge, err = time.ParseDuration(config.UniverseMaxAge)
+		if err != nil {
+			log.Fatalf("Invalid universeMaxAge %s: %s", config.UniverseMaxAge, err)
+		}
 	}
 
-	go pollChat(&client)
-	mainLoop()
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		setupUniverse(ctx, config.UniverseFile, config.SDEPath, universeMaxAge)
+	}()
+	setupPresence(client)
+	setupFormCommands(ctx, client)
+	startWebServer(ctx, config.HTTPAddress)
+
+	go pollChat(ctx, client) // Blocks on the chat connection, so isn't waited for
+	mainLoop(ctx)
+	logging.Infoln("Shutting down")
+	background.Wait()
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	templates "IncursionBot/internal/Templates"
+	"fmt"
+	"net/url"
+)
+
+// Creates the notification destinations from the config, falling back to the default channel if none are configured.
+// Any summaries and room subjects configured for a destination are set up as well.
+func createDestinations(configs []DestinationConfig, defaultChannel string, client Chat.ChatServer) ([]*notifications.Destination, error) {
+	if len(configs) == 0 {
+		configs = []DestinationConfig{{Channel: defaultChannel}}
+	}
+
+	joiner, _ := client.(Chat.ChannelJoiner)
+
+	var result []*notifications.Destination
+	for _, config := range configs {
+		channel := config.Channel
+		if joiner != nil {
+			err := joiner.JoinChannel(channel)
+			if err != nil {
+				return nil, fmt.Errorf("failed to join %s: %w", channel, err)
+			}
+		}
+
+		dest := notifications.Destination{
+			Name:             channel,
+			HomeSpawnsBypass: config.HomeSpawnsBypass,
+			Reminders:        config.Reminders,
+			Send: func(message string) error {
+				return client.BroadcastToChannel(message, channel)
+			},
+			FormatEvent:  formatEvent,
+			FormatDigest: formatDigest,
+		}
+
+		for _, windowConfig := range config.QuietHours {
+			window, err := notifications.ParseQuietHours(windowConfig.Start, windowConfig.End)
+			if err != nil {
+				return nil, err
+			}
+
+			dest.QuietHours = append(dest.QuietHours, window)
+		}
+
+		err := scheduleSummaries(&sched, &dest, config.Summaries)
+		if err != nil {
+			return nil, err
+		}
+
+		subject, err := newRoomSubject(config.Subject, channel, client)
+		if err != nil {
+			return nil, err
+		}
+		if subject != nil {
+			roomSubjects = append(roomSubjects, subject)
+		}
+
+		result = append(result, &dest)
+	}
+
+	return result, nil
+}
+
+// Creates the webhooks from the config
+func createWebhooks(configs []WebhookConfig) ([]*notifications.Webhook, error) {
+	var result []*notifications.Webhook
+
+	for _, config := range configs {
+		target, err := url.Parse(config.URL)
+		if err != nil {
+			return nil, err
+		}
+		if target.Scheme != "http" && target.Scheme != "https" {
+			return nil, fmt.Errorf("webhook URL %q must be http or https", config.URL)
+		}
+
+		result = append(result, &notifications.Webhook{
+			URL:    config.URL,
+			Secret: config.Secret,
+			Filter: notifications.Subscription{
+				Events:   config.Events,
+				Security: config.Security,
+				HomeOnly: config.HomeOnly,
+			},
+			DryRun: dryRun,
+		})
+	}
+
+	return result, nil
+}
+
+// Creates an event for the given incursion, flagging it if it is in a home region
+func newEvent(kind notifications.EventKind, incursion incursions.Incursion) notifications.Event {
+	return notifications.NewEvent(kind, incursion, getHomeRegions().contains(incursion.Region.ID))
+}
+
+// Renders the notification message for a single event
+func formatEvent(event notifications.Event) string {
+	switch event.Kind {
+	case notifications.SpawnEvent:
+		return renderMessage(templates.NewIncursion, templates.NewIncursionData{
+			Incursion: event.Incursion,
+			Home:      event.Home,
+		})
+	case notifications.StateChangeEvent:
+		return renderMessage(templates.StateChange, event.Incursion)
+	case notifications.DespawnEvent:
+		return renderMessage(templates.Despawn, event.Incursion)
+	case notifications.ReminderEvent:
+		return renderMessage(templates.Reminder, event.Reminder)
+	}
+
+	logging.Warningf("No message for unknown event kind %s", event.Kind)
+	return ""
+}
+
+// Renders a single digest message for events held during quiet hours
+func formatDigest(events []notifications.Event) string {
+	var entries []templates.DigestEntry
+
+	for _, event := range events {
+		entries = append(entries, templates.DigestEntry{
+			Time:    event.Time,
+			Message: formatEvent(event),
+		})
+	}
+
+	return renderMessage(templates.Digest, entries)
+}
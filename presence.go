@@ -0,0 +1,65 @@
+package main
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	"IncursionBot/internal/ESI"
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	scheduler "IncursionBot/internal/Scheduler"
+	templates "IncursionBot/internal/Templates"
+	"time"
+)
+
+const presenceInterval time.Duration = time.Minute // Minimum time between presence changes
+
+var presence *notifications.Throttle[Chat.Presence] // Publishes the bot's presence, nil if the chat backend can't
+
+// Sets up presence updates if the chat backend supports them, checking for stale data every minute
+func setupPresence(client Chat.ChatServer) {
+	setter, ok := client.(Chat.PresenceSetter)
+	if !ok {
+		logging.Infoln("Chat backend doesn't support presence, not publishing it")
+		return
+	}
+
+	presence = &notifications.Throttle[Chat.Presence]{
+		Name:     "presence",
+		Interval: presenceInterval,
+		Set:      setter.SetPresence,
+	}
+
+	everyMinute, _ := scheduler.ParseCron("* * * * *")
+	sched.Add("presence check", everyMinute, updatePresence)
+}
+
+// Publishes a summary of the current incursions as the bot's presence, showing away if the data can't be trusted
+func updatePresence() {
+	if presence == nil {
+		return
+	}
+
+	data := templates.PresenceData{Stale: !incursionPolls.Healthy()}
+	for _, endpoint := range esi.Endpoints() {
+		if endpoint.State != ESI.BreakerClosed {
+			data.FailingEndpoints = append(data.FailingEndpoints, endpoint.Endpoint)
+		}
+	}
+	for _, incursion := range incManager.GetIncursions() {
+		switch incursion.Security {
+		case incursions.NullSec:
+			data.Null++
+		case incursions.LowSec:
+			data.Low++
+		}
+
+		if getHomeRegions().contains(incursion.Region.ID) {
+			data.Home = append(data.Home, incursion)
+		}
+	}
+
+	presence.Update(Chat.Presence{
+		Away:   data.Stale,
+		Status: renderMessage(templates.Presence, data),
+	})
+}
@@ -3,12 +3,13 @@ package main
 import (
 	"IncursionBot/internal/ESI"
 	logging "IncursionBot/internal/Logging"
+	"context"
 )
 
-func GetSovOwner(systemID int, esi *ESI.ESIClient) string {
+func GetSovOwner(ctx context.Context, systemID int, esi *ESI.ESIClient) string {
 	result := ""
 
-	sovList, err := esi.GetSovMap()
+	sovList, err := esi.GetSovMap(ctx)
 
 	if err != nil {
 		logging.Errorln("Error occurred getting sov map", err)
@@ -22,7 +23,7 @@ func GetSovOwner(systemID int, esi *ESI.ESIClient) string {
 				return "" // Owned by no one
 			}
 
-			allianceData, err := esi.GetAllianceData(sov.Alliance)
+			allianceData, err := esi.GetAllianceData(ctx, sov.Alliance)
 
 			if err != nil {
 				logging.Errorln("Error occurred getting alliance data", err)
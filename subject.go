@@ -0,0 +1,96 @@
+package main
+
+import (
+	Chat "IncursionBot/internal/ChatClient"
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	templates "IncursionBot/internal/Templates"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const defaultSubjectInterval time.Duration = time.Minute * 5 // Avoid spamming the room with subject changes
+
+// Keeps the subject of a chat room in sync with the current incursions
+type roomSubject struct {
+	template string
+	denied   atomic.Bool // Set once the room refuses a subject change, after which it is left alone
+	throttle notifications.Throttle[string]
+}
+
+// Creates a subject updater for the channel, or nil if it isn't enabled or the chat backend can't set subjects
+func newRoomSubject(config SubjectConfig, channel string, client Chat.ChatServer) (*roomSubject, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	setter, ok := client.(Chat.SubjectSetter)
+	if !ok {
+		logging.Warningf("Chat backend can't set the subject of %s, ignoring subject config", channel)
+		return nil, nil
+	}
+
+	interval := defaultSubjectInterval
+	if config.MinInterval != "" {
+		var err error
+		interval, err = time.ParseDuration(config.MinInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subject := &roomSubject{template: config.Template}
+	subject.throttle = notifications.Throttle[string]{
+		Name:     "subject of " + channel,
+		Interval: interval,
+		Set: func(value string) error {
+			err := setter.SetSubject(channel, value)
+			if errors.Is(err, Chat.ErrSubjectDenied) {
+				subject.denied.Store(true) // The chat backend has already warned about it
+				return nil
+			}
+			return err
+		},
+	}
+
+	if subject.template == "" {
+		subject.template = templates.Subject
+	}
+	if !renderer.Has(subject.template) {
+		return nil, fmt.Errorf("subject template %s doesn't exist", subject.template)
+	}
+
+	return subject, nil
+}
+
+// Renders the subject for the given incursions and updates the room with it
+func (subject *roomSubject) Update(list incursions.IncursionList) {
+	if subject.denied.Load() {
+		return
+	}
+
+	data := templates.SubjectData{
+		Incursions: sortBySecurity(list),
+		NextNull:   incManager.NextSpawn(incursions.NullSec),
+		NextLow:    incManager.NextSpawn(incursions.LowSec),
+	}
+
+	text := renderMessage(subject.template, data)
+	if text != "" {
+		subject.throttle.Update(text)
+	}
+}
+
+// Sorts a copy of the list with nullsec incursions first
+func sortBySecurity(list incursions.IncursionList) incursions.IncursionList {
+	sorted := append(incursions.IncursionList{}, list...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Security == incursions.NullSec && sorted[j].Security != incursions.NullSec
+	})
+
+	return sorted
+}
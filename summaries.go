@@ -0,0 +1,59 @@
+package main
+
+import (
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	scheduler "IncursionBot/internal/Scheduler"
+	templates "IncursionBot/internal/Templates"
+	"fmt"
+	"time"
+)
+
+const (
+	dailySummary  string = "daily"
+	weeklySummary string = "weekly"
+)
+
+const week time.Duration = time.Hour * 24 * 7
+
+// Schedules the configured summary posts for a destination
+func scheduleSummaries(sched *scheduler.Scheduler, dest *notifications.Destination, configs []SummaryConfig) error {
+	for _, config := range configs {
+		schedule, err := scheduler.ParseCron(config.Schedule)
+		if err != nil {
+			return err
+		}
+
+		var createSummary func() string
+		switch config.Type {
+		case dailySummary:
+			createSummary = getDailySummary
+		case weeklySummary:
+			createSummary = getWeeklySummary
+		default:
+			return fmt.Errorf("unknown summary type %q for %s", config.Type, dest.Name)
+		}
+
+		name := fmt.Sprintf("%s summary for %s", config.Type, dest.Name)
+		sched.Add(name, schedule, func() {
+			logging.Infof("Sending %s", name)
+			dest.Post(createSummary())
+		})
+	}
+
+	return nil
+}
+
+// Lists every active spawn and the next spawn windows
+func getDailySummary() string {
+	return renderMessage(templates.DailySummary, templates.DailySummaryData{
+		Time:       time.Now(),
+		Incursions: incManager.GetIncursions(),
+		NextSpawns: incManager.NextSpawns(),
+	})
+}
+
+// Summarises the activity seen over the past week
+func getWeeklySummary() string {
+	return renderMessage(templates.WeeklySummary, activity.Report(time.Now().Add(-week)))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"IncursionBot/internal/ESI"
+	logging "IncursionBot/internal/Logging"
+	scheduler "IncursionBot/internal/Scheduler"
+	universe "IncursionBot/internal/Universe"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const defaultUniverseMaxAge time.Duration = time.Hour * 24 * 30 // New systems and gates are rare, but do happen
+const universeCheckSchedule string = "0 12 * * *"               // Daily, an hour after downtime
+
+var universeBuilding atomic.Bool // Set while the universe is being built, so checks don't start another build
+
+// Loads the universe graph used for routing, adjacency and naming, importing it from the SDE or building it from ESI the
+// first time and whenever it gets older than maxAge. Until it's ready, and for anything it doesn't cover, lookups go to
+// ESI.
+func setupUniverse(ctx context.Context, file string, sdePath string, maxAge time.Duration) {
+	if file == "" && sdePath == "" {
+		return
+	}
+
+	if file != "" {
+		graph, err := universe.Load(file)
+		if err == nil {
+			useUniverse(graph)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			logging.Warningf("Failed to load the universe from %s, building it again: %v", file, err)
+		}
+	}
+
+	checkUniverse(ctx, file, sdePath, maxAge)
+
+	// The bot can run for longer than maxAge. Saves replace the file in one step, so a build cut short by shutdown is harmless.
+	daily, _ := scheduler.ParseCron(universeCheckSchedule)
+	sched.Add("Universe age check", daily, func() {
+		if ctx.Err() == nil {
+			checkUniverse(ctx, file, sdePath, maxAge)
+		}
+	})
+}
+
+// Builds the universe again if there isn't one or it is out of date
+func checkUniverse(ctx context.Context, file string, sdePath string, maxAge time.Duration) {
+	current := esi.Universe()
+	if current != nil {
+		reason := universeOutdated(current, sdePath, maxAge)
+		if reason == "" {
+			return
+		}
+		logging.Infof("Building the universe again, %s", reason)
+	}
+
+	if !universeBuilding.CompareAndSwap(false, true) {
+		return
+	}
+	defer universeBuilding.Store(false)
+
+	buildUniverse(ctx, file, sdePath)
+}
+
+// Says why the universe needs building again, empty if it doesn't
+func universeOutdated(graph *universe.Universe, sdePath string, maxAge time.Duration) string {
+	if sdePath != "" {
+		version, err := universe.SDEVersion(sdePath)
+		if err != nil {
+			logging.Warningf("Failed to read the SDE version from %s: %v", sdePath, err)
+		} else if graph.Source != universe.SourceSDE || graph.Version != version {
+			return fmt.Sprintf("the SDE at %s is version %s, it was built from %s %s", sdePath, version, graph.Source, graph.Version)
+		} else if version != "" {
+			return "" // Importing the same SDE again wouldn't change anything
+		}
+	}
+
+	age := time.Since(graph.BuiltAt)
+	if maxAge > 0 && age > maxAge {
+		return fmt.Sprintf("it was built %s ago", age.Truncate(time.Hour))
+	}
+
+	return ""
+}
+
+// Imports the universe from the SDE, or builds it from ESI if there's no SDE, and saves it to the file
+func buildUniverse(ctx context.Context, file string, sdePath string) {
+	var graph *universe.Universe
+	var err error
+	if sdePath != "" {
+		logging.Infof("Importing the universe from the SDE at %s", sdePath)
+		graph, err = universe.ImportSDE(sdePath)
+	} else {
+		logging.Infoln("Building the universe from ESI, this takes a while")
+		graph, err = esi.BuildUniverse(ctx)
+	}
+
+	if errors.Is(err, ESI.ErrIncompleteUniverse) {
+		if esi.Universe() != nil {
+			logging.Warningln("Keeping the current universe, the new one is incomplete:", err)
+			return
+		}
+
+		// Better than nothing for now, but not kept so it's built again on the next start
+		logging.Warningln("Using an incomplete universe, anything missing is looked up on ESI:", err)
+		useUniverse(graph)
+		return
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.Errorln("Failed to build the universe", err)
+		}
+		return
+	}
+
+	if file != "" {
+		err = graph.Save(file)
+		if err != nil {
+			logging.Errorf("Failed to save the universe to %s: %v", file, err)
+		}
+	}
+
+	useUniverse(graph)
+}
+
+func useUniverse(graph *universe.Universe) {
+	summary := graph.Summary()
+	logging.Infof("Using a universe of %d systems and %d stargates from %s %s, built %s", summary.Systems, summary.Stargates,
+		summary.Source, summary.Version, summary.BuiltAt.UTC().Format("2006-01-02"))
+	esi.UseUniverse(graph)
+}
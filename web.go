@@ -0,0 +1,169 @@
+package main
+
+import (
+	ESI "IncursionBot/internal/ESI"
+	incursions "IncursionBot/internal/Incursions"
+	logging "IncursionBot/internal/Logging"
+	notifications "IncursionBot/internal/Notifications"
+	templates "IncursionBot/internal/Templates"
+	universe "IncursionBot/internal/Universe"
+	web "IncursionBot/internal/Web"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+var liveFeed web.Feed             // Streams events to web clients
+var history notifications.History // Every spawn seen, for the history page
+
+// Full state sent to new live feed clients
+type feedSnapshot struct {
+	Incursions []snapshotIncursion `json:"incursions"`
+	NextNull   predictionPayload   `json:"nextNull"`
+	NextLow    predictionPayload   `json:"nextLow"`
+}
+
+// State of the ESI client, for monitoring
+type esiStatus struct {
+	Health             ESI.Health           `json:"health"`
+	LastPoll           time.Time            `json:"lastPoll"`           // Last successful poll of the incursions
+	IncursionsModified time.Time            `json:"incursionsModified"` // When ESI last changed the incursion data
+	ErrorBudget        ESI.ErrorBudget      `json:"errorBudget"`
+	Cache              ESI.CacheStats       `json:"cache"`
+	Endpoints          []ESI.EndpointStatus `json:"endpoints"`          // Circuit breaker state of each endpoint
+	Universe           *universe.Summary    `json:"universe,omitempty"` // Universe graph lookups are answered from, unset until there is one
+}
+
+func newESIStatus() esiStatus {
+	status := esiStatus{
+		Health:             esi.Health(),
+		LastPoll:           incursionPolls.LastSuccess(),
+		IncursionsModified: esi.IncursionsModified(),
+		ErrorBudget:        esi.ErrorBudget(),
+		Cache:              esi.CacheStats(),
+		Endpoints:          esi.Endpoints(),
+	}
+	if graph := esi.Universe(); graph != nil {
+		summary := graph.Summary()
+		status.Universe = &summary
+	}
+	return status
+}
+
+// Spawn prediction as sent to web clients
+type predictionPayload struct {
+	Kind     incursions.PredictionKind `json:"kind"`
+	Earliest *time.Time                `json:"earliest,omitempty"`
+	Latest   *time.Time                `json:"latest,omitempty"`
+	BasedOn  string                    `json:"basedOn,omitempty"` // Staging system of the incursion the prediction is based on
+	Text     string                    `json:"text"`
+}
+
+func newPredictionPayload(prediction incursions.SpawnPrediction) predictionPayload {
+	payload := predictionPayload{
+		Kind: prediction.Kind,
+		Text: templates.FormatPrediction(prediction),
+	}
+
+	if prediction.Kind != incursions.UnknownPrediction {
+		earliest, latest := prediction.Earliest.UTC(), prediction.Latest.UTC()
+		payload.Earliest = &earliest
+		payload.Latest = &latest
+		payload.BasedOn = prediction.BasedOn.Layout.StagingSystem.Name
+	}
+
+	return payload
+}
+
+type snapshotIncursion struct {
+	notifications.IncursionPayload
+	Home bool `json:"home"`
+}
+
+func newFeedSnapshot(list incursions.IncursionList) feedSnapshot {
+	snapshot := feedSnapshot{
+		Incursions: []snapshotIncursion{},
+		NextNull:   newPredictionPayload(incManager.NextSpawn(incursions.NullSec)),
+		NextLow:    newPredictionPayload(incManager.NextSpawn(incursions.LowSec)),
+	}
+
+	for _, incursion := range list {
+		snapshot.Incursions = append(snapshot.Incursions, snapshotIncursion{
+			IncursionPayload: notifications.NewIncursionPayload(incursion),
+			Home:             getHomeRegions().contains(incursion.Region.ID),
+		})
+	}
+
+	return snapshot
+}
+
+// Turns the recent spawn, state change and despawn events into entries for the Atom and RSS feeds
+func syndicationEntries() []web.SyndicationEntry {
+	var entries []web.SyndicationEntry
+
+	for _, event := range activity.Events(time.Time{}) {
+		switch event.Kind {
+		case notifications.SpawnEvent, notifications.StateChangeEvent, notifications.DespawnEvent:
+		default:
+			continue
+		}
+
+		entries = append(entries, web.SyndicationEntry{
+			ID:       event.ID(),
+			Title:    renderMessage(templates.FeedTitle, event),
+			Content:  formatEvent(event),
+			Link:     templates.DotlanSystemLink(event.Incursion.Layout.StagingSystem.Name),
+			Updated:  event.Time,
+			Security: event.Incursion.Security,
+			Region:   event.Incursion.Region.Name,
+			Home:     event.Home,
+		})
+	}
+
+	return entries
+}
+
+// Serves the web endpoints in the background until the context is cancelled, if an address is configured
+func startWebServer(ctx context.Context, address string) {
+	if address == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", web.Dashboard())
+	mux.HandleFunc("GET /events", liveFeed.ServeSSE)
+	mux.HandleFunc("GET /ws", liveFeed.ServeWebSocket)
+	mux.HandleFunc("GET /api/incursions", web.JSONHandler(func() any { return newFeedSnapshot(incManager.GetIncursions()) }))
+	mux.HandleFunc("GET /api/history", web.JSONHandler(func() any { return history.Records() }))
+	mux.HandleFunc("GET /api/esi", web.JSONHandler(func() any { return newESIStatus() }))
+
+	syndication := web.Syndication{Title: "Incursions", Entries: syndicationEntries}
+	syndication.Register(mux)
+	mux.Handle("GET /calendar.ics", &web.Calendar{Name: "Incursions", Events: calendarEvents})
+
+	server := &http.Server{
+		Addr:        address,
+		Handler:     mux,
+		BaseContext: func(net.Listener) context.Context { return ctx }, // Ends live feed streams on shutdown
+	}
+
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		logging.Infof("Serving HTTP on %s", address)
+		err := server.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			logging.Errorf("HTTP server stopped: %v", err)
+		}
+	}()
+
+	go func() {
+		defer background.Done()
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+}